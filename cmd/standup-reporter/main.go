@@ -4,13 +4,21 @@ Standup-Reporter gathers data for daily standup reports and prints it to the scr
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
 
+	"golang.org/x/xerrors"
 	"gopkg.in/alecthomas/kingpin.v2"
 
 	"github.com/jeremy-miller/standup-reporter/internal/asana"
 	"github.com/jeremy-miller/standup-reporter/internal/configuration"
+	"github.com/jeremy-miller/standup-reporter/internal/report"
+	"github.com/jeremy-miller/standup-reporter/internal/source"
 )
 
 // set by release process
@@ -21,17 +29,298 @@ var (
 )
 
 func main() {
+	source.Register(asana.Name, asana.New)
+	app := kingpin.New("standup-reporter", "Command-line application to gather daily standup reports.")
+	configPath, required := configFilePath(os.Args[1:])
+	file, err := configuration.LoadFile(configPath, required)
+	app.FatalIfError(err, "")
+	given := make(map[string]bool) // names of the flags given on the command line
 	var (
-		app        = kingpin.New("standup-reporter", "Command-line application to gather daily standup reports.")
-		days       = app.Flag("days", "Number of days to go back to collect completed tasks. Default 1 day (or 3 days on Monday).").Short('d').PlaceHolder("N").Int() //nolint:lll
-		asanaToken = app.Flag("asana", "Asana Personal Access Token").Short('a').Required().PlaceHolder("TOKEN").String()
+		_               = app.Flag("config", "Path of the YAML configuration file.").Envar("STANDUP_CONFIG").PlaceHolder("FILE").String()                                                                                                                                                                                               //nolint:lll
+		sources         = app.Flag("source", "Source to gather tasks from. Repeat for multiple sources.").Envar("STANDUP_SOURCE").Short('s').Default(listDefaults(file.Sources, asana.Name)...).PlaceHolder("SOURCE").Enums(source.Names()...)                                                                                          //nolint:lll
+		format          = app.Flag("format", "Output format of the report: "+strings.Join(report.Formats(), ", ")+".").Envar("STANDUP_FORMAT").Short('f').Default(defaults(file.Format, report.TextFormat)...).PlaceHolder("FORMAT").Enum(report.Formats()...)                                                                          //nolint:lll
+		tmpl            = app.Flag("template", "Path of a Go text/template to render the report with, instead of the output format.").Envar("STANDUP_TEMPLATE").Default(defaults(file.Template)...).PlaceHolder("FILE").String()                                                                                                        //nolint:lll
+		sortKeys        = app.Flag("sort", "Key to sort the tasks of each section by: "+strings.Join(report.SortKeys(), ", ")+". Repeat for tie breakers. Default completion time for completed tasks, project and name for planned tasks.").Envar("STANDUP_SORT").Default(file.Sort...).PlaceHolder("KEY").Enums(report.SortKeys()...) //nolint:lll
+		groupBy         = app.Flag("group-by", "Field to group the tasks of each section by: "+strings.Join(report.GroupFields(), ", ")+".").Envar("STANDUP_GROUP_BY").Default(defaults(file.GroupBy, report.GroupNone)...).PlaceHolder("FIELD").Enum(report.GroupFields()...)                                                          //nolint:lll
+		subtasks        = app.Flag("subtasks", "How to report subtasks: "+strings.Join(configuration.SubtaskModes(), ", ")+" (e.g. \"3/5 subtasks done\").").Envar("STANDUP_SUBTASKS").Default(defaults(file.Subtasks, configuration.SubtasksOff)...).PlaceHolder("MODE").Enum(configuration.SubtaskModes()...)                         //nolint:lll
+		activity        = app.Flag("activity", "Report the tasks you commented on or attached files to and the project status updates you posted.").Envar("STANDUP_ACTIVITY").Default(defaults(boolValue(file.Activity))...).Bool()                                                                                                     //nolint:lll
+		days            = app.Flag("days", "Number of days to go back to collect completed tasks. Default back to the previous working day (see --work-days and --holiday).").Envar("STANDUP_DAYS").Short('d').Default(defaults(intValue(file.Days))...).PlaceHolder("N").Action(flagGiven(given, "days")).Int()                        //nolint:lll
+		timezone        = app.Flag("timezone", "IANA name of the timezone in which days start and times are shown, e.g. America/New_York or UTC. Default local timezone.").Short('z').Envar("STANDUP_TIMEZONE").Default(defaults(file.Timezone)...).PlaceHolder("ZONE").String()                                                        //nolint:lll
+		workDays        = app.Flag("work-days", "Working days of the week, e.g. mon-fri, sun-thu or mon,tue,thu. Repeatable. Default mon-fri.").Envar("STANDUP_WORK_DAYS").Default(file.WorkDays...).PlaceHolder("DAYS").Strings()                                                                                                      //nolint:lll
+		holidays        = app.Flag("holiday", "Holiday (YYYY-MM-DD) or path of an .ics file of holidays, which aren't working days. Repeatable.").Envar("STANDUP_HOLIDAY").Default(file.Holidays...).PlaceHolder("HOLIDAY").Strings()                                                                                                   //nolint:lll
+		since           = app.Flag("since", "Start of the window for completed tasks, e.g. 2019-06-03, 2019-06-03T09:00:00-07:00, \"last friday\" or \"2 weeks ago\". Overrides --days.").Envar("STANDUP_SINCE").PlaceHolder("DATE").Action(flagGiven(given, "since")).String()                                                         //nolint:lll
+		until           = app.Flag("until", "End of the window for completed tasks, in the same formats as --since. Dates are inclusive. Default yesterday.").Envar("STANDUP_UNTIL").Default(defaults(file.Until)...).PlaceHolder("DATE").String()                                                                                      //nolint:lll
+		sinceLast       = app.Flag("since-last", "Report tasks completed since the end of the window of the last run, so no days are missed when a standup is skipped.").Envar("STANDUP_SINCE_LAST").Action(flagGiven(given, "since-last")).Bool()                                                                                      //nolint:lll
+		stateFile       = app.Flag("state-file", "Path of the file the last run is recorded in.").Envar("STANDUP_STATE_FILE").Default(defaults(file.StateFile, configuration.DefaultStatePath())...).PlaceHolder("FILE").String()                                                                                                       //nolint:lll
+		today           = app.Flag("today", "How to report tasks completed today: "+strings.Join(configuration.TodayModes(), ", ")+" (in a \"Done Since Midnight\" section).").Envar("STANDUP_TODAY").Default(defaults(file.Today, configuration.TodayOff)...).PlaceHolder("MODE").Enum(configuration.TodayModes()...)                  //nolint:lll
+		todayCutoff     = app.Flag("today-cutoff", "Time of day (HH:MM) until which tasks completed today are reported. Default now.").Envar("STANDUP_TODAY_CUTOFF").Default(defaults(file.TodayCutoff)...).PlaceHolder("HH:MM").String()                                                                                               //nolint:lll
+		asanaToken      = app.Flag("asana", "Asana Personal Access Token").Envar("STANDUP_ASANA_TOKEN").Short('a').Default(defaults(file.Asana.Token)...).PlaceHolder("TOKEN").Action(flagGiven(given, "asana")).String()                                                                                                               //nolint:lll
+		asanaTokenFile  = app.Flag("asana-token-file", "Path of a file containing the Asana Personal Access Token.").Envar("STANDUP_ASANA_TOKEN_FILE").Default(defaults(file.Asana.TokenFile)...).PlaceHolder("FILE").Action(flagGiven(given, "asana-token-file")).String()                                                             //nolint:lll
+		asanaTokenCmd   = app.Flag("asana-token-cmd", "Shell command which prints the Asana Personal Access Token (e.g. \"pass show asana\").").Envar("STANDUP_ASANA_TOKEN_CMD").Default(defaults(file.Asana.TokenCmd)...).PlaceHolder("COMMAND").Action(flagGiven(given, "asana-token-cmd")).String()                                  //nolint:lll
+		allAssignees    = app.Flag("all-assignees", "Report tasks assigned to anyone, not just the authenticated user.").Envar("STANDUP_ALL_ASSIGNEES").Default(defaults(boolValue(file.Asana.AllAssignees))...).Bool()                                                                                                                 //nolint:lll
+		workspaces      = app.Flag("workspace", "Name or GID of an Asana workspace to report on. Repeat for multiple workspaces.").Envar("STANDUP_WORKSPACE").Short('w').Default(file.Asana.Workspaces...).PlaceHolder("WORKSPACE").Strings()                                                                                           //nolint:lll
+		allWorkspaces   = app.Flag("all-workspaces", "Report on all Asana workspaces.").Envar("STANDUP_ALL_WORKSPACES").Default(defaults(boolValue(file.Asana.AllWorkspaces))...).Bool()                                                                                                                                                //nolint:lll
+		projects        = app.Flag("project", "Only use Asana projects whose names match this glob or /regex/. Repeatable.").Envar("STANDUP_PROJECT").Short('p').Default(file.Asana.Projects...).PlaceHolder("PATTERN").Strings()                                                                                                       //nolint:lll
+		excludeProjects = app.Flag("exclude-project", "Skip Asana projects whose names match this glob or /regex/. Repeatable.").Envar("STANDUP_EXCLUDE_PROJECT").Default(file.Asana.ExcludeProjects...).PlaceHolder("PATTERN").Strings()                                                                                               //nolint:lll
+		teams           = app.Flag("team", "Only use Asana projects whose team names match this glob or /regex/. Repeatable.").Envar("STANDUP_TEAM").Default(file.Asana.Teams...).PlaceHolder("PATTERN").Strings()                                                                                                                      //nolint:lll
+		excludeTeams    = app.Flag("exclude-team", "Skip Asana projects whose team names match this glob or /regex/. Repeatable.").Envar("STANDUP_EXCLUDE_TEAM").Default(file.Asana.ExcludeTeams...).PlaceHolder("PATTERN").Strings()                                                                                                   //nolint:lll
+		sections        = app.Flag("section", "Only use Asana tasks in sections whose names match this glob or /regex/. Repeatable.").Envar("STANDUP_SECTION").Default(file.Asana.Sections...).PlaceHolder("PATTERN").Strings()                                                                                                         //nolint:lll
+		excludeSections = app.Flag("exclude-section", "Skip Asana tasks in sections whose names match this glob or /regex/. Repeatable.").Envar("STANDUP_EXCLUDE_SECTION").Default(file.Asana.ExcludeSections...).PlaceHolder("PATTERN").Strings()                                                                                      //nolint:lll
+		blockedSections = app.Flag("blocked-section", "Report incomplete Asana tasks in sections whose names match this glob or /regex/ as blockers. Repeatable.").Envar("STANDUP_BLOCKED_SECTION").Default(file.Asana.BlockedSections...).PlaceHolder("PATTERN").Strings()                                                             //nolint:lll
+		blockedTags     = app.Flag("blocked-tag", "Report incomplete Asana tasks with tags whose names match this glob or /regex/ as blockers. Repeatable.").Envar("STANDUP_BLOCKED_TAG").Default(file.Asana.BlockedTags...).PlaceHolder("PATTERN").Strings()                                                                           //nolint:lll
+		blockedFields   = app.Flag("blocked-field", "Report incomplete Asana tasks whose enum custom field NAME has the value VALUE (each a glob or /regex/) as blockers. Repeatable.").Envar("STANDUP_BLOCKED_FIELD").Default(file.Asana.BlockedFields...).PlaceHolder("NAME=VALUE").Strings()                                         //nolint:lll
+		blockedByDeps   = app.Flag("blocked-by-deps", "Report incomplete Asana tasks with incomplete dependencies as blockers.").Envar("STANDUP_BLOCKED_BY_DEPS").Default(defaults(boolValue(file.Asana.BlockedByDeps))...).Bool()                                                                                                      //nolint:lll
+		includeArchived = app.Flag("include-archived", "Use archived Asana projects, which are skipped by default.").Envar("STANDUP_INCLUDE_ARCHIVED").Default(defaults(boolValue(file.Asana.IncludeArchived))...).Bool()                                                                                                               //nolint:lll
+		planned         = app.Flag("planned", "Only plan incomplete tasks which are due (today or overdue), started or in a planned section. Repeatable. Default all incomplete tasks.").Envar("STANDUP_PLANNED").Default(file.Asana.Planned...).PlaceHolder("POLICY").Enums(configuration.PlannedPolicies()...)                        //nolint:lll
+		dueWithin       = app.Flag("due-within", "Also plan tasks due within this number of days, with --planned due.").Envar("STANDUP_DUE_WITHIN").Default(defaults(intValue(file.Asana.DueWithin))...).PlaceHolder("N").Int()                                                                                                         //nolint:lll
+		plannedSections = app.Flag("planned-section", "Glob or /regex/ of the names of sections whose tasks are planned, with --planned section. Repeatable. Default \"In Progress\".").Envar("STANDUP_PLANNED_SECTION").Default(file.Asana.PlannedSections...).PlaceHolder("PATTERN").Strings()                                        //nolint:lll
+		maxAttempts     = app.Flag("max-attempts", "Maximum number of attempts for each Asana request.").Envar("STANDUP_MAX_ATTEMPTS").Default(defaults(intValue(file.Asana.MaxAttempts), "4")...).PlaceHolder("N").Int()                                                                                                               //nolint:lll
+		maxRetryWait    = app.Flag("max-retry-wait", "Maximum time to wait between attempts of an Asana request.").Envar("STANDUP_MAX_RETRY_WAIT").Default(defaults(durationValue(file.Asana.MaxRetryWait), "30s")...).PlaceHolder("DURATION").Duration()                                                                               //nolint:lll
+		concurrency     = app.Flag("concurrency", "Maximum number of Asana projects to retrieve tasks for concurrently.").Envar("STANDUP_CONCURRENCY").Default(defaults(intValue(file.Asana.Concurrency), "4")...).PlaceHolder("N").Int()                                                                                               //nolint:lll
 	)
 	app.HelpFlag.Short('h')
 	app.Version(fmt.Sprintf("Version: %s\nCommit: %s\nBuild Date: %s", version, commit, date))
 	kingpin.MustParse(app.Parse(os.Args[1:]))
-	fmt.Println("Running standup reporter")
-	config := configuration.Get(*days)
-	if err := asana.Report(*asanaToken, config); err != nil {
-		fmt.Printf("\n%v\n", err)
+	fmt.Fprintln(os.Stderr, "Running standup reporter")
+	calendar, err := configuration.NewCalendar(*workDays, *holidays)
+	app.FatalIfError(err, "")
+	location, err := configuration.LoadLocation(*timezone)
+	app.FatalIfError(err, "")
+	config := configuration.Get(*days, calendar, location)
+	state, err := configuration.LoadState(*stateFile)
+	app.FatalIfError(err, "")
+	start, startLast := windowStart(given, *since, *sinceLast, file)
+	app.FatalIfError(config.SetWindow(start, *until), "")
+	if startLast && start == "" { // the last run is looked up relative to the end of the window
+		if !config.SinceLast(state) {
+			fmt.Fprintln(os.Stderr, "No previous run recorded, using the default window")
+		}
 	}
+	app.FatalIfError(config.SetToday(*today, *todayCutoff, time.Now()), "")
+	config.Sources = *sources
+	config.Format = *format
+	config.Template = *tmpl
+	config.Sort = *sortKeys
+	config.GroupBy = *groupBy
+	config.Subtasks = *subtasks
+	config.Activity = *activity
+	config.AsanaToken, err = configuration.ResolveSecret(os.Stderr,
+		configuration.Secret{Value: flagValue(given, "asana", *asanaToken), File: flagValue(given, "asana-token-file", *asanaTokenFile), Command: flagValue(given, "asana-token-cmd", *asanaTokenCmd)}, //nolint:lll
+		configuration.Secret{Value: os.Getenv("STANDUP_ASANA_TOKEN"), File: os.Getenv("STANDUP_ASANA_TOKEN_FILE"), Command: os.Getenv("STANDUP_ASANA_TOKEN_CMD")},                                      //nolint:lll
+		configuration.Secret{Value: file.Asana.Token, File: file.Asana.TokenFile, Command: file.Asana.TokenCmd},
+	)
+	app.FatalIfError(err, "")
+	config.AllAssignees = *allAssignees
+	config.Workspaces = *workspaces
+	config.AllWorkspaces = *allWorkspaces
+	config.Projects = *projects
+	config.ExcludeProjects = *excludeProjects
+	config.Teams = *teams
+	config.ExcludeTeams = *excludeTeams
+	config.Sections = *sections
+	config.ExcludeSections = *excludeSections
+	config.BlockedSections = *blockedSections
+	config.BlockedTags = *blockedTags
+	config.BlockedFields = *blockedFields
+	config.BlockedByDeps = *blockedByDeps
+	config.IncludeArchived = *includeArchived
+	config.Planned = *planned
+	config.DueWithin = *dueWithin
+	config.PlannedSections = *plannedSections
+	config.MaxAttempts = *maxAttempts
+	config.MaxRetryWait = *maxRetryWait
+	config.Concurrency = *concurrency
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go cancelOnInterrupt(cancel)
+	complete, err := run(ctx, config)
+	app.FatalIfError(err, "")
+	if !complete { // the tasks which couldn't be retrieved must still be reported by the next --since-last run
+		fmt.Fprintln(os.Stderr, "warning: not all tasks could be retrieved, so this run isn't recorded")
+		return
+	}
+	if state.Record(config, time.Now()) {
+		if err := state.Save(*stateFile); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+		}
+	}
+}
+
+/*
+run queries all enabled sources concurrently and renders a report of their merged results.  It reports whether the
+report is complete, i.e. no source failed to retrieve some of its items.
+*/
+func run(ctx context.Context, config *configuration.Configuration) (bool, error) {
+	renderer, err := newRenderer(config)
+	if err != nil {
+		return false, err
+	}
+	sources, err := source.New(config.Sources, config)
+	if err != nil {
+		return false, err
+	}
+	fmt.Fprintln(os.Stderr, "\nGathering data...")
+	until, end := config.LatestDate, config.LatestDate
+	if !config.TodayEnd.IsZero() {
+		until = config.TodayEnd
+		if config.MergeToday {
+			end = config.TodayEnd
+		}
+	}
+	result := source.Collect(ctx, sources, config.EarliestDate, until)
+	if ctx.Err() != nil {
+		return false, ctx.Err()
+	}
+	standup := report.New(config.EarliestDate, end, result)
+	if err := arrange(standup, config); err != nil {
+		return false, err
+	}
+	return len(standup.Errors) == 0, renderer.Render(os.Stdout, standup)
+}
+
+/*
+arrange sorts and groups the items of the report as configured.  It fails if there are no items to render, unless the
+report is rendered as JSON.
+*/
+func arrange(standup *report.Report, config *configuration.Configuration) error {
+	standup.RollUp = config.Subtasks == configuration.SubtasksRollUp
+	if err := standup.Sort(config.Sort); err != nil {
+		return err
+	}
+	if err := standup.Group(config.GroupBy); err != nil {
+		return err
+	}
+	if standup.Empty() && config.Format != report.JSONFormat { // an empty JSON report is still valid output
+		for _, err := range standup.Errors {
+			fmt.Fprintf(os.Stderr, "\n%v\n", err)
+		}
+		return xerrors.New("no tasks available")
+	}
+	return nil
+}
+
+/*
+newRenderer returns the renderer for the configured template, if any, or else for the configured output format.
+*/
+func newRenderer(config *configuration.Configuration) (report.Renderer, error) {
+	if config.Template != "" {
+		return report.NewTemplate(config.Template)
+	}
+	return report.NewRenderer(config.Format)
+}
+
+/*
+configFilePath returns the path of the configuration file given by the --config flag or the STANDUP_CONFIG environment
+variable, which must exist, or else the default path, which may not exist.  The arguments are scanned before they are
+parsed, since the configuration file provides the defaults of the other flags.
+*/
+func configFilePath(args []string) (string, bool) {
+	for i, arg := range args {
+		switch {
+		case arg == "--":
+			return configuration.DefaultFilePath(), false
+		case strings.HasPrefix(arg, "--config="):
+			return strings.TrimPrefix(arg, "--config="), true
+		case arg == "--config" && i+1 < len(args):
+			return args[i+1], true
+		}
+	}
+	if path := os.Getenv("STANDUP_CONFIG"); path != "" {
+		return path, true
+	}
+	return configuration.DefaultFilePath(), false
+}
+
+/*
+flagGiven returns an action which records that a flag was given on the command line, rather than taking its value from
+its environment variable or the configuration file.
+*/
+func flagGiven(given map[string]bool, name string) kingpin.Action {
+	return func(*kingpin.ParseContext) error {
+		given[name] = true
+		return nil
+	}
+}
+
+/*
+flagValue returns the value of a flag if it was given on the command line, or else an empty string.
+*/
+func flagValue(given map[string]bool, name, value string) string {
+	if given[name] {
+		return value
+	}
+	return ""
+}
+
+/*
+windowStart returns the start of the window (--since and --since-last) given on the command line or by environment
+variables, or else by the configuration file.  The start of the window from the configuration file is only used if none
+of --days, --since and --since-last are given, since it would otherwise override a number of days given on the command
+line.
+*/
+func windowStart(given map[string]bool, since string, sinceLast bool, file *configuration.File) (string, bool) {
+	if flagSet(given, "days") || flagSet(given, "since") || flagSet(given, "since-last") {
+		return since, sinceLast
+	}
+	return file.Since, file.SinceLast
+}
+
+/*
+flagSet reports whether a flag was given on the command line or by its environment variable.
+*/
+func flagSet(given map[string]bool, name string) bool {
+	return given[name] || os.Getenv("STANDUP_"+strings.ToUpper(strings.Replace(name, "-", "_", -1))) != ""
+}
+
+/*
+defaults returns the default values of a flag, which is the value from the configuration file if it is set, or else
+the fallback (if any).
+*/
+func defaults(value string, fallback ...string) []string {
+	if value != "" {
+		return []string{value}
+	}
+	return fallback
+}
+
+/*
+listDefaults returns the default values of a repeatable flag, which are the values from the configuration file if any
+are set, or else the fallback.
+*/
+func listDefaults(values []string, fallback ...string) []string {
+	if len(values) > 0 {
+		return values
+	}
+	return fallback
+}
+
+func intValue(value int) string {
+	if value == 0 {
+		return ""
+	}
+	return strconv.Itoa(value)
+}
+
+func boolValue(value bool) string {
+	if !value {
+		return ""
+	}
+	return strconv.FormatBool(value)
+}
+
+func durationValue(value time.Duration) string {
+	if value == 0 {
+		return ""
+	}
+	return value.String()
+}
+
+/*
+cancelOnInterrupt stops any in-flight requests when the user interrupts the program (e.g. Ctrl+C).
+*/
+func cancelOnInterrupt(cancel context.CancelFunc) {
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	<-interrupt
+	cancel()
 }
@@ -0,0 +1,183 @@
+package asana
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"github.com/jeremy-miller/standup-reporter/internal/source"
+)
+
+/*
+story is an event in the history of a task, such as a comment or an attachment being added.
+*/
+type story struct {
+	Gid             string    `json:"gid"`
+	CreatedAt       time.Time `json:"created_at"`
+	CreatedBy       *entry    `json:"created_by"`
+	ResourceSubtype string    `json:"resource_subtype"`
+}
+
+type projectStatus struct {
+	Gid       string    `json:"gid"`
+	Title     string    `json:"title"`
+	CreatedAt time.Time `json:"created_at"`
+	CreatedBy *entry    `json:"created_by"`
+}
+
+/*
+activity is something the user did other than completing a task, such as commenting on a task or posting a project
+status update.
+*/
+type activity struct {
+	Gid       string // GID of the story or status update, so activity on tasks in several projects is only reported once.
+	Name      string // Description of the activity (e.g. "Commented on Task 1").
+	Project   string
+	URL       string
+	CreatedAt time.Time
+}
+
+//nolint:gochecknoglobals
+var storyActions = map[string]string{ // descriptions of the story subtypes which are reported as activity
+	"comment_added":    "Commented on",
+	"attachment_added": "Attached file to",
+}
+
+/*
+allActivity retrieves the activity of the user within [since, until) in all projects, for at most c.concurrency
+projects concurrently.  Errors for individual projects are aggregated and returned alongside the activity of the
+projects which succeeded.
+*/
+func (c *client) allActivity(ctx context.Context, projects []project, userGID string, since, until time.Time) ([]activity, error) { //nolint:lll
+	var mu sync.Mutex
+	var activities []activity
+	seen := make(map[string]bool)
+	errs := forEach(ctx, len(projects), c.concurrency, func(i int) error {
+		projectActivities, err := c.projectActivity(ctx, projects[i], userGID, since, until)
+		mu.Lock()
+		defer mu.Unlock()
+		for _, a := range projectActivities {
+			if !seen[a.Gid] {
+				seen[a.Gid] = true
+				activities = append(activities, a)
+			}
+		}
+		return err
+	})
+	if ctx.Err() != nil {
+		return activities, xerrors.Errorf("error retrieving activity: %w", ctx.Err())
+	}
+	if len(errs) > 0 {
+		return activities, errs
+	}
+	return activities, nil
+}
+
+/*
+projectActivity retrieves the status updates the user posted to a project within [since, until), along with the
+comments and attachments they added to its tasks.
+*/
+func (c *client) projectActivity(ctx context.Context, project project, userGID string, since, until time.Time) ([]activity, error) { //nolint:lll
+	activities, err := c.statusActivity(ctx, project, userGID, since, until)
+	if err != nil {
+		return nil, err
+	}
+	storyActivities, err := c.storyActivity(ctx, project, userGID, since, until)
+	return append(activities, storyActivities...), err
+}
+
+/*
+statusActivity retrieves the status updates the user posted to a project within [since, until).
+*/
+func (c *client) statusActivity(ctx context.Context, project project, userGID string, since, until time.Time) ([]activity, error) { //nolint:lll
+	path := fmt.Sprintf("projects/%s/project_statuses?opt_fields=title,created_at,created_by", project.Gid)
+	var statuses []projectStatus
+	if err := c.requestAll(ctx, path, &statuses); err != nil {
+		err = explain(err, "project "+project.Name)
+		return nil, xerrors.Errorf("error requesting status updates for project %s: %v", project.Name, err)
+	}
+	var activities []activity
+	for _, status := range statuses {
+		if authoredWithin(status.CreatedBy, status.CreatedAt, userGID, since, until) {
+			activities = append(activities, activity{
+				Gid:       status.Gid,
+				Name:      fmt.Sprintf("Posted status update on %s: %s", project.Name, status.Title),
+				Project:   project.Name,
+				CreatedAt: status.CreatedAt,
+			})
+		}
+	}
+	return activities, nil
+}
+
+/*
+storyActivity retrieves the comments and attachments the user added to the tasks of a project within [since, until).
+Only the stories of tasks modified since the start of the window are retrieved, since adding a story modifies its task.
+*/
+func (c *client) storyActivity(ctx context.Context, project project, userGID string, since, until time.Time) ([]activity, error) { //nolint:lll
+	path := fmt.Sprintf("tasks?project=%s&modified_since=%s&opt_fields=name,permalink_url", project.Gid, since.UTC().Format(time.RFC3339)) //nolint:lll
+	var tasks []task
+	if err := c.requestAll(ctx, path, &tasks); err != nil {
+		err = explain(err, "project "+project.Name)
+		return nil, xerrors.Errorf("error requesting modified tasks for project %s: %v", project.Name, err)
+	}
+	var activities []activity
+	for _, task := range filterEmptyTasks(tasks) {
+		task.Project = project.Name
+		taskActivities, err := c.taskActivity(ctx, task, userGID, since, until)
+		activities = append(activities, taskActivities...)
+		if err != nil {
+			return activities, err
+		}
+	}
+	return activities, nil
+}
+
+/*
+taskActivity retrieves the comments and attachments the user added to a task within [since, until).  Each kind of story
+is reported once per task, when the user first added it within the window.
+*/
+func (c *client) taskActivity(ctx context.Context, task task, userGID string, since, until time.Time) ([]activity, error) { //nolint:lll
+	path := fmt.Sprintf("tasks/%s/stories?opt_fields=created_at,created_by,resource_subtype", task.Gid)
+	var stories []story
+	if err := c.requestAll(ctx, path, &stories); err != nil {
+		err = explain(err, "task "+task.Name)
+		return nil, xerrors.Errorf("error requesting stories of task %s: %v", task.Name, err)
+	}
+	var activities []activity
+	reported := make(map[string]bool) // several comments on a task are reported once
+	for _, story := range stories {
+		action, ok := storyActions[story.ResourceSubtype]
+		if ok && !reported[action] && authoredWithin(story.CreatedBy, story.CreatedAt, userGID, since, until) {
+			reported[action] = true
+			activities = append(activities, activity{
+				Gid:       story.Gid,
+				Name:      fmt.Sprintf("%s %s", action, task.Name),
+				Project:   task.Project,
+				URL:       task.PermalinkURL,
+				CreatedAt: story.CreatedAt,
+			})
+		}
+	}
+	return activities, nil
+}
+
+/*
+authoredWithin reports whether something was created by the user within [since, until).
+*/
+func authoredWithin(author *entry, createdAt time.Time, userGID string, since, until time.Time) bool {
+	return author != nil && author.Gid == userGID && !createdAt.Before(since) && createdAt.Before(until)
+}
+
+func (a activity) item(location *time.Location) source.Item {
+	return source.Item{
+		Source:      Name,
+		Project:     a.Project,
+		Name:        a.Name,
+		URL:         a.URL,
+		CompletedAt: a.CreatedAt.In(location),
+	}
+}
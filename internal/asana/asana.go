@@ -1,14 +1,33 @@
 /*
-Package asana contains all functionality for retrieving tasks from Asana and printing them to the screen.
+Package asana implements the Asana source of the standup-reporter.
 
-Tasks from all Asana projects are used in the standup-reporter.  Unless specified, the default number of days to go back
-and get tasks for is 1, except if the script is run on a Monday, in which case it will go back 3 days (to account for
-the weekend).
+Tasks from all Asana projects in the selected workspaces are used.  When more than one workspace is selected, tasks are
+labeled with their workspace so the report can be grouped by workspace.  Only tasks assigned to the authenticated user
+are used, unless all assignees are requested.
 
-Completed tasks are sorted oldest to most recently completed.  Only tasks which were completed between midnight of the
-requested day and midnight of the current day (both in local time) are shown.
+Archived projects are skipped unless requested, and projects may be further limited by including or excluding project
+and team names.  Tasks may be limited by including or excluding the names of their sections (e.g. board columns).  Name
+filters are case insensitive globs (e.g. "Eng*") or, when wrapped in slashes, regular expressions.
 
-Regarding incomplete tasks, all non-complete tasks are shown and are not sorted.
+Requests which are rate limited, fail with a server error or fail due to a network error are retried with exponential
+backoff, honoring any Retry-After header sent by Asana.  Requests which Asana rejects are reported with a description of
+the likely cause (e.g. an invalid token or a deleted project) along with the error message returned by Asana.
+
+Completed tasks are those completed within the requested window, while planned tasks are all incomplete tasks unless
+planned-task policies are set.  Policies plan tasks which are due (today, overdue or within some days), which have
+started, or which are in matching sections (e.g. "In Progress").  Incomplete tasks which are blocked are reported as
+blockers instead, based on their section (e.g. "Blocked"), their tags, the values of their enum custom fields (e.g.
+"Status" is "Blocked") or their incomplete dependencies.
+
+Subtasks aren't tasks of projects, so they are only retrieved (for the tasks which have any) when they are reported.
+Completed tasks are then reported with their subtasks completed within the window, tasks which aren't completed are
+also reported as completed if some of their subtasks were, and planned tasks are reported with their incomplete
+subtasks.  Subtasks can be assigned to someone other than the assignee of their parent, so the subtasks of tasks of any
+assignee are retrieved, and tasks assigned to someone else are only reported for their subtasks assigned to the user.
+
+When requested, the activity of the authenticated user is reported too: the comments and attachments they added to
+tasks of any assignee within the window, found in the stories of tasks modified since its start, and the status updates
+they posted to projects.
 */
 package asana
 
@@ -18,63 +37,133 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
-	"sort"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"golang.org/x/xerrors"
-
-	"github.com/jeremy-miller/standup-reporter/internal/configuration"
 )
 
 type client struct {
-	authToken string
-	baseURL   *url.URL
-	client    http.Client
+	authToken    string
+	baseURL      *url.URL
+	client       http.Client
+	retry        retryPolicy
+	concurrency  int    // Maximum number of projects to retrieve tasks for concurrently.
+	assigneeGID  string // If set, only tasks assigned to this user are retrieved.
+	withSubtasks bool   // Whether subtasks are retrieved, so tasks of other assignees which have subtasks are kept.
 }
 
 type response struct {
-	Data interface{} `json:"data"`
+	Data     interface{} `json:"data"`
+	NextPage *nextPage   `json:"next_page"`
+}
+
+type nextPage struct {
+	Offset string `json:"offset"`
 }
 
 type entry struct {
-	Gid string `json:"gid"`
+	Gid  string `json:"gid"`
+	Name string `json:"name"`
 }
 
 type task struct {
-	Completed   bool      `json:"completed"`
-	CompletedAt time.Time `json:"completed_at"`
-	Name        string    `json:"name"`
+	Gid          string        `json:"gid"`
+	Assignee     *entry        `json:"assignee"`
+	Completed    bool          `json:"completed"`
+	CompletedAt  time.Time     `json:"completed_at"`
+	CreatedAt    time.Time     `json:"created_at"`
+	DueOn        string        `json:"due_on"`   // Due date (YYYY-MM-DD), if any.
+	DueAt        *time.Time    `json:"due_at"`   // Due time, if the task is due at a specific time.
+	StartOn      string        `json:"start_on"` // Start date (YYYY-MM-DD), if any.
+	Name         string        `json:"name"`
+	PermalinkURL string        `json:"permalink_url"`
+	Projects     []entry       `json:"projects"`
+	Tags         []entry       `json:"tags"`
+	Memberships  []membership  `json:"memberships"`
+	CustomFields []customField `json:"custom_fields"`
+	NumSubtasks  int           `json:"num_subtasks"`
+	Subtasks     []task        `json:"-"`            // Only retrieved if subtasks are reported.
+	Dependencies []dependency  `json:"dependencies"` // Tasks which must be completed before this task.
+	Project      string        `json:"-"`            // Name of the project the task was retrieved from.
+	Section      string        `json:"-"`            // Name of the task's section in the project it was retrieved from.
+	Workspace    string        `json:"-"`            // Only set if tasks are retrieved from more than one workspace.
+	ForSubtasks  bool          `json:"-"`            // Assigned to someone else, so only reported for the user's subtasks.
+}
+
+type membership struct {
+	Project entry  `json:"project"`
+	Section *entry `json:"section"`
+}
+
+type customField struct {
+	Name      string `json:"name"`
+	EnumValue *entry `json:"enum_value"` // Only set for enum custom fields with a value.
+}
+
+type dependency struct {
+	Gid       string `json:"gid"`
+	Name      string `json:"name"`
+	Completed bool   `json:"completed"`
+}
+
+/*
+dueDate returns the date (YYYY-MM-DD) the task is due in the given timezone, or an empty string if it has no due date.
+*/
+func (t task) dueDate(location *time.Location) string {
+	if t.DueAt != nil {
+		return t.DueAt.In(location).Format(dateLayout)
+	}
+	return t.DueOn
 }
 
-type taskResult struct {
-	Tasks []task
-	Err   error
+/*
+completedWithin reports whether the task was completed within [since, until).
+*/
+func (t task) completedWithin(since, until time.Time) bool {
+	return t.Completed && !t.CompletedAt.Before(since) && t.CompletedAt.Before(until)
 }
 
 /*
-Report coordinates gathering of Asana task data and prints completed and incomplete tasks to the screen.
+openDependencies returns the names of the incomplete tasks this task depends on.
 */
-func Report(authToken string, config *configuration.Configuration) error {
-	fmt.Println("\nGathering Asana data...")
-	client := getClient(authToken)
-	workspaceGID, err := client.workspaceGID()
+func (t task) openDependencies() []string {
+	var names []string
+	for _, dependency := range t.Dependencies {
+		if !dependency.Completed {
+			names = append(names, dependency.Name)
+		}
+	}
+	return names
+}
+
+/*
+due returns the time the task is due in the given timezone, which is midnight if it is due on a date, or the zero time
+if it has no (valid) due date.
+*/
+func (t task) due(location *time.Location) time.Time {
+	if t.DueAt != nil {
+		return t.DueAt.In(location)
+	}
+	due, err := time.ParseInLocation(dateLayout, t.DueOn, location)
 	if err != nil {
-		return xerrors.Errorf("error retrieving workspace: %w", err)
+		return time.Time{}
 	}
-	projectGIDs, err := client.projectGIDs(workspaceGID)
+	return due
+}
+
+func (c *client) workspaceTasks(ctx context.Context, workspace entry, filter *projectFilter, since time.Time) ([]task, error) { //nolint:lll
+	projectGIDs, err := c.projectGIDs(ctx, workspace.Gid, filter)
 	if err != nil {
-		return xerrors.Errorf("error retrieving projects: %w", err)
+		return nil, xerrors.Errorf("error retrieving projects: %w", explain(err, "workspace "+workspace.Name))
 	}
 	if len(projectGIDs) == 0 {
-		return xerrors.New("no projects in workspace")
-	}
-	tasks := client.allTasks(projectGIDs, config)
-	if len(tasks) == 0 {
-		return xerrors.New("no tasks available")
+		return nil, xerrors.New("no projects in workspace match the project filters")
 	}
-	printCompletedTasks(tasks, config)
-	printIncompleteTasks(tasks)
-	return nil
+	return c.allTasks(ctx, projectGIDs, since)
 }
 
 func getClient(authToken string) *client {
@@ -86,91 +175,300 @@ func getClient(authToken string) *client {
 		client: http.Client{
 			Timeout: time.Second * 10,
 		},
+		retry: defaultRetryPolicy(),
 	}
 }
 
 func (c *client) request(ctx context.Context, path string, responseObj interface{}) error {
+	_, err := c.requestPage(ctx, path, responseObj)
+	return err
+}
+
+/*
+requestAll follows the "next_page" offsets returned by Asana until all pages of a paginated endpoint have been
+retrieved.  The data of each page is appended to responseObj, which must be a pointer to a slice.
+*/
+func (c *client) requestAll(ctx context.Context, path string, responseObj interface{}) error {
+	allData := reflect.ValueOf(responseObj).Elem()
+	offset := ""
+	for {
+		pagePath, err := paginatedPath(path, offset)
+		if err != nil {
+			return err
+		}
+		pageData := reflect.New(allData.Type())
+		next, err := c.requestPage(ctx, pagePath, pageData.Interface())
+		if err != nil {
+			return err
+		}
+		allData.Set(reflect.AppendSlice(allData, pageData.Elem()))
+		if next == nil || next.Offset == "" {
+			return nil
+		}
+		offset = next.Offset
+	}
+}
+
+func paginatedPath(path, offset string) (string, error) {
+	const pageLimit = 100 // maximum allowed by Asana
 	relPath, err := url.Parse(path)
 	if err != nil {
-		return xerrors.Errorf("error parsing relative path \"%s\": %w", path, err)
+		return "", xerrors.Errorf("error parsing relative path \"%s\": %w", path, err)
+	}
+	query := relPath.Query()
+	query.Set("limit", strconv.Itoa(pageLimit))
+	if offset != "" {
+		query.Set("offset", offset)
+	}
+	relPath.RawQuery = query.Encode()
+	return relPath.String(), nil
+}
+
+/*
+requestPage requests a single page, retrying rate-limited requests, server errors and network errors according to the
+client's retry policy.
+*/
+func (c *client) requestPage(ctx context.Context, path string, responseObj interface{}) (*nextPage, error) {
+	relPath, err := url.Parse(path)
+	if err != nil {
+		return nil, xerrors.Errorf("error parsing relative path \"%s\": %w", path, err)
 	}
 	fullURL := c.baseURL.ResolveReference(relPath).String()
+	for attempt := 1; ; attempt++ {
+		next, err := c.doRequest(ctx, fullURL, responseObj)
+		if err == nil {
+			return next, nil
+		}
+		if attempt >= c.retry.maxAttempts || !retryable(ctx, err) {
+			return nil, err
+		}
+		if waitErr := c.retry.wait(ctx, attempt, err); waitErr != nil {
+			return nil, err
+		}
+	}
+}
+
+func (c *client) doRequest(ctx context.Context, fullURL string, responseObj interface{}) (*nextPage, error) {
 	req, _ := http.NewRequest("GET", fullURL, nil) //nolint:errcheck
 	authHeader := fmt.Sprintf("Bearer %s", c.authToken)
 	req.Header.Set("Authorization", authHeader)
 	res, err := c.client.Do(req.WithContext(ctx))
 	if err != nil {
-		return xerrors.Errorf("error requesting \"%s\": %w", fullURL, err)
+		return nil, xerrors.Errorf("error requesting \"%s\": %w", fullURL, err)
 	}
 	defer res.Body.Close()
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+		var body errorResponse
+		json.NewDecoder(res.Body).Decode(&body) //nolint:errcheck,gosec // status code is reported even if body isn't JSON
+		apiErr := newAPIError(res.StatusCode, body)
+		apiErr.RetryAfter = parseRetryAfter(res.Header.Get("Retry-After"), time.Now())
+		return nil, xerrors.Errorf("error requesting \"%s\": %w", fullURL, apiErr)
+	}
 	parsedResponse := &response{Data: responseObj}
 	if err = json.NewDecoder(res.Body).Decode(parsedResponse); err != nil {
-		return xerrors.Errorf("error decoding response from \"%s\": %w", fullURL, err)
+		return nil, xerrors.Errorf("error decoding response from \"%s\": %w", fullURL, err)
 	}
-	return nil
+	return parsedResponse.NextPage, nil
 }
 
-func (c *client) workspaceGID() (string, error) {
-	ctx := context.Background()
+func (c *client) currentUser(ctx context.Context) (entry, error) {
+	const path = "users/me"
+	user := new(entry)
+	if err := c.request(ctx, path, user); err != nil {
+		return entry{}, err
+	}
+	return *user, nil
+}
+
+func (c *client) workspaces(ctx context.Context) ([]entry, error) {
 	const path = "workspaces"
 	workspaces := new([]entry)
-	if err := c.request(ctx, path, workspaces); err != nil {
-		return "", err
+	if err := c.requestAll(ctx, path, workspaces); err != nil {
+		return nil, err
+	}
+	return *workspaces, nil
+}
+
+/*
+selectWorkspaces returns the workspaces matching the given selectors, each of which is either the name (case
+insensitive) or GID of a workspace.  If no selectors are given, the only workspace is used.
+*/
+func selectWorkspaces(workspaces []entry, selectors []string, all bool) ([]entry, error) {
+	if len(workspaces) == 0 {
+		return nil, xerrors.New("no workspaces available for this Asana account")
+	}
+	if all {
+		return workspaces, nil
+	}
+	if len(selectors) == 0 {
+		if len(workspaces) > 1 {
+			return nil, xerrors.Errorf("multiple workspaces available, select one or more with --workspace or use --all-workspaces: %s", workspaceNames(workspaces)) //nolint:lll
+		}
+		return workspaces, nil
+	}
+	var selected []entry
+	seen := make(map[string]bool)
+	for _, selector := range selectors {
+		workspace, ok := findWorkspace(workspaces, selector)
+		if !ok {
+			return nil, xerrors.Errorf("workspace \"%s\" not found, available workspaces: %s", selector, workspaceNames(workspaces)) //nolint:lll
+		}
+		if !seen[workspace.Gid] {
+			seen[workspace.Gid] = true
+			selected = append(selected, workspace)
+		}
+	}
+	return selected, nil
+}
+
+func findWorkspace(workspaces []entry, selector string) (entry, bool) {
+	for _, workspace := range workspaces {
+		if workspace.Gid == selector || strings.EqualFold(workspace.Name, selector) {
+			return workspace, true
+		}
+	}
+	return entry{}, false
+}
+
+func workspaceNames(workspaces []entry) string {
+	names := make([]string, 0, len(workspaces))
+	for _, workspace := range workspaces {
+		names = append(names, workspace.Name)
 	}
-	return (*workspaces)[0].Gid, nil
+	return strings.Join(names, ", ")
 }
 
-func (c *client) projectGIDs(workspaceGID string) ([]string, error) {
-	ctx := context.Background()
-	path := fmt.Sprintf("workspaces/%s/projects", workspaceGID)
-	allProjects := new([]entry)
-	if err := c.request(ctx, path, allProjects); err != nil {
+func (c *client) projectGIDs(ctx context.Context, workspaceGID string, filter *projectFilter) ([]string, error) {
+	projects, err := c.projects(ctx, workspaceGID, filter)
+	if err != nil {
 		return nil, err
 	}
-	var projects []string
-	for _, project := range *allProjects {
-		projects = append(projects, project.Gid)
+	var projectGIDs []string
+	for _, project := range projects {
+		projectGIDs = append(projectGIDs, project.Gid)
 	}
-	return projects, nil
+	return projectGIDs, nil
 }
 
-func (c *client) allTasks(projectGIDs []string, config *configuration.Configuration) []task {
-	results := make(chan taskResult)
-	for _, projectGID := range projectGIDs {
-		config.WG.Add(1)
-		go projectTasks(c, projectGID, config, results)
+/*
+projects retrieves the projects of a workspace which match the project filters.
+*/
+func (c *client) projects(ctx context.Context, workspaceGID string, filter *projectFilter) ([]project, error) {
+	path := fmt.Sprintf("workspaces/%s/projects?opt_fields=name,archived,team.name", workspaceGID)
+	allProjects := new([]project)
+	if err := c.requestAll(ctx, path, allProjects); err != nil {
+		return nil, err
 	}
-	go func() {
-		config.WG.Wait()
-		close(results)
-	}()
+	return filter.apply(*allProjects), nil
+}
+
+/*
+allTasks retrieves the tasks of all projects using a bounded pool of workers.  Errors for individual projects are
+aggregated and returned alongside the tasks of the projects which succeeded.
+*/
+func (c *client) allTasks(ctx context.Context, projectGIDs []string, since time.Time) ([]task, error) {
+	var mu sync.Mutex
 	var tasks []task
-	for r := range results {
-		if r.Err != nil {
-			fmt.Printf("%v", r.Err)
-			continue
+	errs := forEach(ctx, len(projectGIDs), c.concurrency, func(i int) error {
+		projectTasks, err := c.projectTasks(ctx, projectGIDs[i], since)
+		mu.Lock()
+		defer mu.Unlock()
+		tasks = append(tasks, projectTasks...)
+		return err
+	})
+	if ctx.Err() != nil {
+		return tasks, xerrors.Errorf("error retrieving tasks: %w", ctx.Err())
+	}
+	if len(errs) > 0 {
+		return tasks, projectErrors(errs)
+	}
+	return tasks, nil
+}
+
+/*
+forEach calls fn with each index from 0 to n-1, using a bounded pool of workers so at most concurrency calls run at
+once, and stops starting new calls once the context is canceled.  The errors returned by fn are aggregated.
+*/
+func forEach(ctx context.Context, n, concurrency int, fn func(i int) error) errorList {
+	indexes := make(chan int)
+	var mu sync.Mutex
+	var errs errorList
+	var wg sync.WaitGroup
+	for w := 0; w < workerCount(concurrency, n); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				if err := fn(i); err != nil {
+					mu.Lock()
+					errs = append(errs, err)
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	feedIndexes(ctx, indexes, n)
+	wg.Wait()
+	return errs
+}
+
+/*
+feedIndexes sends the indexes from 0 to n-1 until the context is canceled, and then closes the channel.
+*/
+func feedIndexes(ctx context.Context, indexes chan<- int, n int) {
+	defer close(indexes)
+	for i := 0; i < n; i++ {
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+			return
 		}
-		tasks = append(tasks, r.Tasks...)
 	}
-	return tasks
 }
 
-func projectTasks(c *client, projectGID string, config *configuration.Configuration, results chan<- taskResult) {
-	defer config.WG.Done()
-	ctx := context.Background()
-	path := fmt.Sprintf("projects/%s/tasks?opt_fields=name,completed,completed_at&completed_since=%s", projectGID, config.EarliestDate) //nolint:lll
+func workerCount(concurrency, projectCount int) int {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	if concurrency > projectCount {
+		return projectCount
+	}
+	return concurrency
+}
+
+func (c *client) projectTasks(ctx context.Context, projectGID string, since time.Time) ([]task, error) {
+	path := fmt.Sprintf("projects/%s/tasks?opt_fields=name,completed,completed_at,created_at,num_subtasks,due_on,due_at,start_on,assignee,permalink_url,projects.name,tags.name,memberships.project.name,memberships.section.name,custom_fields.name,custom_fields.enum_value.name,dependencies.name,dependencies.completed&completed_since=%s", projectGID, since.UTC().Format(time.RFC3339)) //nolint:lll
 	var tasks []task
-	if err := c.request(ctx, path, &tasks); err != nil {
-		results <- taskResult{
-			Tasks: nil,
-			Err:   xerrors.Errorf("error requesting tasks for project %s: %v", projectGID, err),
-		}
-		return
+	if err := c.requestAll(ctx, path, &tasks); err != nil {
+		err = explain(err, "project "+projectGID)
+		return nil, xerrors.Errorf("error requesting tasks for project %s: %v", projectGID, err)
 	}
 	filteredTasks := filterEmptyTasks(tasks)
-	results <- taskResult{
-		Tasks: filteredTasks,
-		Err:   nil,
+	if c.assigneeGID != "" {
+		filteredTasks = filterAssignedTasks(filteredTasks, c.assigneeGID, c.withSubtasks)
+	}
+	setProject(filteredTasks, projectGID)
+	return filteredTasks, nil
+}
+
+/*
+setProject labels the tasks with the name of the project they were retrieved from, which is one of the (possibly
+multiple) projects each task belongs to, and with their section in that project.
+*/
+func setProject(tasks []task, projectGID string) {
+	for i := range tasks {
+		for _, project := range tasks[i].Projects {
+			if project.Gid == projectGID {
+				tasks[i].Project = project.Name
+				break
+			}
+		}
+		for _, membership := range tasks[i].Memberships {
+			if membership.Project.Gid == projectGID && membership.Section != nil {
+				tasks[i].Section = membership.Section.Name
+				break
+			}
+		}
 	}
 }
 
@@ -184,30 +482,24 @@ func filterEmptyTasks(tasks []task) []task {
 	return filteredTasks
 }
 
-func printCompletedTasks(tasks []task, config *configuration.Configuration) {
-	var completedTasks []task
-	for _, task := range tasks {
-		if task.Completed && task.CompletedAt.Before(config.TodayMidnight) {
-			completedTasks = append(completedTasks, task)
+/*
+filterAssignedTasks keeps the tasks assigned to the user.  If subtasks are retrieved, tasks assigned to someone else
+which have subtasks are kept too, marked so they are only reported for the subtasks assigned to the user.
+*/
+func filterAssignedTasks(tasks []task, assigneeGID string, subtasks bool) []task {
+	var filteredTasks []task
+	for i, task := range tasks {
+		switch {
+		case task.assignedTo(assigneeGID):
+			filteredTasks = append(filteredTasks, tasks[i])
+		case subtasks && task.NumSubtasks > 0:
+			tasks[i].ForSubtasks = true
+			filteredTasks = append(filteredTasks, tasks[i])
 		}
 	}
-	sort.Slice(completedTasks, func(i, j int) bool { return completedTasks[i].CompletedAt.Before(completedTasks[j].CompletedAt) }) //nolint:lll
-	fmt.Println("\nYesterday's Activity:")
-	for _, task := range completedTasks {
-		fmt.Println("-", task.Name)
-	}
+	return filteredTasks
 }
 
-func printIncompleteTasks(tasks []task) {
-	var incompleteTasks []task
-	for _, task := range tasks {
-		if !task.Completed {
-			incompleteTasks = append(incompleteTasks, task)
-		}
-	}
-	fmt.Println("\nToday's Planned Activity:")
-	for _, task := range incompleteTasks {
-		fmt.Println("-", task.Name)
-	}
-	fmt.Println()
+func (t task) assignedTo(assigneeGID string) bool {
+	return t.Assignee != nil && t.Assignee.Gid == assigneeGID
 }
@@ -1,21 +1,21 @@
 package asana
 
 import (
-	"bytes"
 	"context"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
-	"os"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/xerrors"
 
 	"github.com/jeremy-miller/standup-reporter/internal/configuration"
+	"github.com/jeremy-miller/standup-reporter/internal/source"
 )
 
 var (
@@ -36,12 +36,23 @@ func setup() {
 	server = httptest.NewServer(mux)
 	u, _ := url.Parse(server.URL) //nolint:errcheck
 	cl.baseURL = u
+	cl.retry.baseDelay = time.Millisecond
+	cl.retry.maxWait = time.Millisecond * 10
 }
 
 func teardown() {
 	server.Close()
 }
 
+/*
+decodedTime returns a time as it is decoded from the RFC 3339 timestamp of an Asana response, so it can be compared with
+decoded times regardless of the local timezone.
+*/
+func decodedTime(t time.Time) time.Time {
+	decoded, _ := time.Parse(time.RFC3339, t.Format(time.RFC3339)) //nolint:errcheck
+	return decoded
+}
+
 func TestGetClient(t *testing.T) {
 	assert := assert.New(t)
 	authToken := "123abc"
@@ -51,6 +62,7 @@ func TestGetClient(t *testing.T) {
 	assert.Equal(defaultBaseURL, c.baseURL.String())
 	assert.IsType(http.Client{}, c.client)
 	assert.Equal(time.Second*10, c.client.Timeout)
+	assert.Equal(defaultRetryPolicy(), c.retry)
 }
 
 func TestRequestSuccess(t *testing.T) {
@@ -100,28 +112,311 @@ func TestRequestInvalidJSON(t *testing.T) {
 	assert.Error(err)
 }
 
-func TestWorkspaceGIDSuccess(t *testing.T) {
+func TestRequestErrorStatus(t *testing.T) {
+	setup()
+	defer teardown()
+	assert := assert.New(t)
+	mux.HandleFunc("/test", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, `{"errors":[
+			{"message":"Not Authorized","help":"Read the docs"}
+		]}`)
+	})
+	responseObj := new([]testObj)
+	err := cl.request(context.Background(), "test", responseObj)
+	var apiErr *APIError
+	assert.True(xerrors.As(err, &apiErr))
+	expected := &APIError{StatusCode: http.StatusUnauthorized, Messages: []string{"Not Authorized"}, Help: "Read the docs"}
+	assert.Equal(expected, apiErr)
+}
+
+func TestRequestErrorStatusInvalidJSON(t *testing.T) {
+	setup()
+	defer teardown()
+	assert := assert.New(t)
+	mux.HandleFunc("/test", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+		fmt.Fprint(w, "Bad Gateway")
+	})
+	responseObj := new([]testObj)
+	err := cl.request(context.Background(), "test", responseObj)
+	var apiErr *APIError
+	assert.True(xerrors.As(err, &apiErr))
+	expected := &APIError{StatusCode: http.StatusBadGateway}
+	assert.Equal(expected, apiErr)
+}
+
+func TestRequestRetrySuccess(t *testing.T) {
+	setup()
+	defer teardown()
+	assert := assert.New(t)
+	attempts := 0
+	mux.HandleFunc("/test", func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		switch attempts {
+		case 1:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+		case 2:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			fmt.Fprint(w, `{"data":[
+				{"gid":"1","name":"test"}
+			]}`)
+		}
+	})
+	responseObj := new([]testObj)
+	err := cl.request(context.Background(), "test", responseObj)
+	assert.Nil(err)
+	assert.Equal(3, attempts)
+	expected := []testObj{
+		{Gid: "1", Name: "test"},
+	}
+	assert.Equal(&expected, responseObj)
+}
+
+func TestRequestRetryExhausted(t *testing.T) {
+	setup()
+	defer teardown()
+	assert := assert.New(t)
+	attempts := 0
+	mux.HandleFunc("/test", func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	responseObj := new([]testObj)
+	err := cl.request(context.Background(), "test", responseObj)
+	var apiErr *APIError
+	assert.True(xerrors.As(err, &apiErr))
+	assert.Equal(http.StatusInternalServerError, apiErr.StatusCode)
+	assert.Equal(cl.retry.maxAttempts, attempts)
+}
+
+func TestRequestRetryAfterExceedsMaxWait(t *testing.T) {
+	setup()
+	defer teardown()
+	assert := assert.New(t)
+	attempts := 0
+	mux.HandleFunc("/test", func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+	responseObj := new([]testObj)
+	err := cl.request(context.Background(), "test", responseObj)
+	var apiErr *APIError
+	assert.True(xerrors.As(err, &apiErr))
+	assert.Equal(time.Second*60, apiErr.RetryAfter)
+	assert.Equal(1, attempts)
+}
+
+func TestRequestNoRetryClientError(t *testing.T) {
+	setup()
+	defer teardown()
+	assert := assert.New(t)
+	attempts := 0
+	mux.HandleFunc("/test", func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusBadRequest)
+	})
+	responseObj := new([]testObj)
+	err := cl.request(context.Background(), "test", responseObj)
+	assert.Error(err)
+	assert.Equal(1, attempts)
+}
+
+func TestRequestRetryCanceledContext(t *testing.T) {
+	setup()
+	defer teardown()
+	assert := assert.New(t)
+	attempts := 0
+	ctx, cancel := context.WithCancel(context.Background())
+	mux.HandleFunc("/test", func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		cancel()
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	responseObj := new([]testObj)
+	err := cl.request(ctx, "test", responseObj)
+	assert.Error(err)
+	assert.Equal(1, attempts)
+}
+
+func TestRequestAllMultiplePages(t *testing.T) {
+	setup()
+	defer teardown()
+	assert := assert.New(t)
+	mux.HandleFunc("/test", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal("100", r.URL.Query().Get("limit"))
+		switch r.URL.Query().Get("offset") {
+		case "":
+			fmt.Fprint(w, `{"data":[
+				{"gid":"1","name":"test 1"}
+			],"next_page":{"offset":"abc","path":"/test?limit=100&offset=abc"}}`)
+		case "abc":
+			fmt.Fprint(w, `{"data":[
+				{"gid":"2","name":"test 2"}
+			],"next_page":null}`)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	})
+	responseObj := new([]testObj)
+	err := cl.requestAll(context.Background(), "test", responseObj)
+	assert.Nil(err)
+	expected := []testObj{
+		{Gid: "1", Name: "test 1"},
+		{Gid: "2", Name: "test 2"},
+	}
+	assert.Equal(&expected, responseObj)
+}
+
+func TestRequestAllInvalidPath(t *testing.T) {
+	responseObj := new([]testObj)
+	err := cl.requestAll(context.Background(), ":", responseObj)
+	assert.Error(t, err)
+}
+
+func TestRequestAllInvalidSecondPage(t *testing.T) {
+	setup()
+	defer teardown()
+	mux.HandleFunc("/test", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("offset") == "" {
+			fmt.Fprint(w, `{"data":[
+				{"gid":"1","name":"test 1"}
+			],"next_page":{"offset":"abc"}}`)
+			return
+		}
+		fmt.Fprint(w, `{"data":[
+			{"gid":2,"name":"test 2"}
+		]}`)
+	})
+	responseObj := new([]testObj)
+	err := cl.requestAll(context.Background(), "test", responseObj)
+	assert.Error(t, err)
+}
+
+func TestPaginatedPath(t *testing.T) {
+	testCases := []struct {
+		name     string
+		path     string
+		offset   string
+		expected string
+	}{
+		{name: "FirstPage", path: "test", offset: "", expected: "test?limit=100"},
+		{name: "NextPage", path: "test", offset: "abc", expected: "test?limit=100&offset=abc"},
+		{
+			name:     "ExistingQuery",
+			path:     "test?opt_fields=name",
+			offset:   "abc",
+			expected: "test?limit=100&offset=abc&opt_fields=name",
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := paginatedPath(tc.path, tc.offset)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestCurrentUserSuccess(t *testing.T) {
+	setup()
+	defer teardown()
+	assert := assert.New(t)
+	mux.HandleFunc("/users/me", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":{"gid":"1","name":"User 1"}}`)
+	})
+	actualUser, err := cl.currentUser(context.Background())
+	assert.Nil(err)
+	expectedUser := entry{Gid: "1", Name: "User 1"}
+	assert.Equal(expectedUser, actualUser)
+}
+
+func TestCurrentUserFailure(t *testing.T) {
+	setup()
+	defer teardown()
+	_, err := cl.currentUser(context.Background())
+	assert.NotNil(t, err)
+}
+
+func TestWorkspacesSuccess(t *testing.T) {
 	setup()
 	defer teardown()
 	assert := assert.New(t)
 	mux.HandleFunc("/workspaces", func(w http.ResponseWriter, r *http.Request) {
 		fmt.Fprint(w, `{"data":[
-			{"gid":"1","name":"Workspace 1"}
+			{"gid":"1","name":"Workspace 1"},
+			{"gid":"2","name":"Workspace 2"}
 		]}`)
 	})
-	actualGID, err := cl.workspaceGID()
+	actualWorkspaces, err := cl.workspaces(context.Background())
 	assert.Nil(err)
-	expectedGID := "1"
-	assert.Equal(expectedGID, actualGID)
+	expectedWorkspaces := []entry{
+		{Gid: "1", Name: "Workspace 1"},
+		{Gid: "2", Name: "Workspace 2"},
+	}
+	assert.Equal(expectedWorkspaces, actualWorkspaces)
 }
 
-func TestWorkspaceGIDFailure(t *testing.T) {
+func TestWorkspacesFailure(t *testing.T) {
 	setup()
 	defer teardown()
-	_, err := cl.workspaceGID()
+	_, err := cl.workspaces(context.Background())
 	assert.NotNil(t, err)
 }
 
+func TestSelectWorkspaces(t *testing.T) {
+	workspace1 := entry{Gid: "1", Name: "Workspace 1"}
+	workspace2 := entry{Gid: "2", Name: "Workspace 2"}
+	testCases := []struct {
+		name       string
+		workspaces []entry
+		selectors  []string
+		all        bool
+		expected   []entry
+		err        string
+	}{
+		{name: "NoWorkspaces", workspaces: nil, err: "no workspaces available"},
+		{name: "NoWorkspacesAll", workspaces: nil, all: true, err: "no workspaces available"},
+		{name: "OnlyWorkspace", workspaces: []entry{workspace1}, expected: []entry{workspace1}},
+		{name: "MultipleUnselected", workspaces: []entry{workspace1, workspace2}, err: "multiple workspaces available"},
+		{name: "All", workspaces: []entry{workspace1, workspace2}, all: true, expected: []entry{workspace1, workspace2}},
+		{
+			name:       "ByName",
+			workspaces: []entry{workspace1, workspace2},
+			selectors:  []string{"workspace 2"},
+			expected:   []entry{workspace2},
+		},
+		{
+			name:       "ByGIDAndName",
+			workspaces: []entry{workspace1, workspace2},
+			selectors:  []string{"2", "Workspace 1", "Workspace 2"},
+			expected:   []entry{workspace2, workspace1},
+		},
+		{
+			name:       "NotFound",
+			workspaces: []entry{workspace1, workspace2},
+			selectors:  []string{"Workspace 3"},
+			err:        "workspace \"Workspace 3\" not found, available workspaces: Workspace 1, Workspace 2",
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := selectWorkspaces(tc.workspaces, tc.selectors, tc.all)
+			if tc.err != "" {
+				assert.Contains(t, err.Error(), tc.err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
 func TestProjectGIDsSuccessSomeProjects(t *testing.T) {
 	setup()
 	defer teardown()
@@ -134,7 +429,30 @@ func TestProjectGIDsSuccessSomeProjects(t *testing.T) {
 			{"gid":"2","name":"Project 2"}
 		]}`)
 	})
-	actualProjectsGIDs, err := cl.projectGIDs(workspaceGID)
+	actualProjectsGIDs, err := cl.projectGIDs(context.Background(), workspaceGID, &projectFilter{})
+	assert.Nil(err)
+	expectedProjectGIDs := []string{"1", "2"}
+	assert.Equal(expectedProjectGIDs, actualProjectsGIDs)
+}
+
+func TestProjectGIDsSuccessMultiplePages(t *testing.T) {
+	setup()
+	defer teardown()
+	assert := assert.New(t)
+	const workspaceGID = "12345"
+	pattern := fmt.Sprintf("/workspaces/%s/projects", workspaceGID)
+	mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("offset") == "" {
+			fmt.Fprint(w, `{"data":[
+				{"gid":"1","name":"Project 1"}
+			],"next_page":{"offset":"abc"}}`)
+			return
+		}
+		fmt.Fprint(w, `{"data":[
+			{"gid":"2","name":"Project 2"}
+		],"next_page":null}`)
+	})
+	actualProjectsGIDs, err := cl.projectGIDs(context.Background(), workspaceGID, &projectFilter{})
 	assert.Nil(err)
 	expectedProjectGIDs := []string{"1", "2"}
 	assert.Equal(expectedProjectGIDs, actualProjectsGIDs)
@@ -149,37 +467,315 @@ func TestProjectGIDsSuccessNoProjects(t *testing.T) {
 	mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
 		fmt.Fprint(w, `{"data":[]}`)
 	})
-	actualProjectsGIDs, err := cl.projectGIDs(workspaceGID)
+	actualProjectsGIDs, err := cl.projectGIDs(context.Background(), workspaceGID, &projectFilter{})
 	assert.Nil(err)
 	var expectedProjectGIDs []string
 	assert.Equal(expectedProjectGIDs, actualProjectsGIDs)
 }
 
+func TestProjectGIDsSuccessFiltered(t *testing.T) {
+	setup()
+	defer teardown()
+	assert := assert.New(t)
+	const workspaceGID = "12345"
+	pattern := fmt.Sprintf("/workspaces/%s/projects", workspaceGID)
+	mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal("name,archived,team.name", r.URL.Query().Get("opt_fields"))
+		fmt.Fprint(w, `{"data":[
+			{"gid":"1","name":"Project 1","archived":false,"team":{"gid":"10","name":"Team 1"}},
+			{"gid":"2","name":"Project 2","archived":true,"team":{"gid":"10","name":"Team 1"}},
+			{"gid":"3","name":"Other","archived":false,"team":{"gid":"10","name":"Team 1"}}
+		]}`)
+	})
+	filter, err := newProjectFilter(&configuration.Configuration{Projects: []string{"project*"}})
+	assert.Nil(err)
+	actualProjectsGIDs, err := cl.projectGIDs(context.Background(), workspaceGID, filter)
+	assert.Nil(err)
+	expectedProjectGIDs := []string{"1"}
+	assert.Equal(expectedProjectGIDs, actualProjectsGIDs)
+}
+
 func TestProjectGIDsFailure(t *testing.T) {
 	setup()
 	defer teardown()
 	const workspaceGID = "12345"
-	_, err := cl.projectGIDs(workspaceGID)
+	_, err := cl.projectGIDs(context.Background(), workspaceGID, &projectFilter{})
 	assert.NotNil(t, err)
 }
 
+func TestNewProjectFilterInvalid(t *testing.T) {
+	testCases := []struct {
+		name   string
+		config *configuration.Configuration
+	}{
+		{name: "Projects", config: &configuration.Configuration{Projects: []string{"/(/"}}},
+		{name: "ExcludeProjects", config: &configuration.Configuration{ExcludeProjects: []string{"/(/"}}},
+		{name: "Teams", config: &configuration.Configuration{Teams: []string{"/(/"}}},
+		{name: "ExcludeTeams", config: &configuration.Configuration{ExcludeTeams: []string{"/(/"}}},
+		{name: "Sections", config: &configuration.Configuration{Sections: []string{"/(/"}}},
+		{name: "ExcludeSections", config: &configuration.Configuration{ExcludeSections: []string{"/(/"}}},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := newProjectFilter(tc.config)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestProjectFilterMatches(t *testing.T) {
+	team := &entry{Gid: "10", Name: "Engineering"}
+	testCases := []struct {
+		name     string
+		config   *configuration.Configuration
+		project  project
+		expected bool
+	}{
+		{name: "NoFilters", config: &configuration.Configuration{}, project: project{Name: "Project"}, expected: true},
+		{name: "Archived", config: &configuration.Configuration{}, project: project{Name: "Project", Archived: true}},
+		{
+			name:     "IncludeArchived",
+			config:   &configuration.Configuration{IncludeArchived: true},
+			project:  project{Name: "Project", Archived: true},
+			expected: true,
+		},
+		{
+			name:     "IncludedProject",
+			config:   &configuration.Configuration{Projects: []string{"Other", "proj*"}},
+			project:  project{Name: "Project"},
+			expected: true,
+		},
+		{
+			name:    "NotIncludedProject",
+			config:  &configuration.Configuration{Projects: []string{"Other"}},
+			project: project{Name: "Project"},
+		},
+		{
+			name:    "ExcludedProject",
+			config:  &configuration.Configuration{Projects: []string{"*"}, ExcludeProjects: []string{"/^Pro/"}},
+			project: project{Name: "Project"},
+		},
+		{
+			name:     "IncludedTeam",
+			config:   &configuration.Configuration{Teams: []string{"eng*"}},
+			project:  project{Name: "Project", Team: team},
+			expected: true,
+		},
+		{
+			name:    "NoTeamIncludedTeams",
+			config:  &configuration.Configuration{Teams: []string{"eng*"}},
+			project: project{Name: "Project"},
+		},
+		{
+			name:     "NoTeamExcludedTeams",
+			config:   &configuration.Configuration{ExcludeTeams: []string{"eng*"}},
+			project:  project{Name: "Project"},
+			expected: true,
+		},
+		{
+			name:    "ExcludedTeam",
+			config:  &configuration.Configuration{ExcludeTeams: []string{"Engineering"}},
+			project: project{Name: "Project", Team: team},
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			filter, err := newProjectFilter(tc.config)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expected, filter.matches(tc.project))
+		})
+	}
+}
+
+func TestNewPlannedFilterInvalid(t *testing.T) {
+	testCases := []struct {
+		name   string
+		config *configuration.Configuration
+	}{
+		{name: "Policy", config: &configuration.Configuration{Planned: []string{"soon"}}},
+		{
+			name:   "Sections",
+			config: &configuration.Configuration{Planned: []string{"section"}, PlannedSections: []string{"/(/"}},
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := newPlannedFilter(tc.config)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestPlannedFilterMatches(t *testing.T) {
+	today := time.Date(2019, time.June, 5, 0, 0, 0, 0, time.UTC)
+	dueAt := time.Date(2019, time.June, 6, 3, 0, 0, 0, time.UTC) // June 5 in Los Angeles
+	testCases := []struct {
+		name     string
+		config   *configuration.Configuration
+		task     task
+		expected bool
+	}{
+		{name: "NoPolicies", config: &configuration.Configuration{}, task: task{}, expected: true},
+		{name: "DueToday", config: &configuration.Configuration{Planned: []string{"due"}}, task: task{DueOn: "2019-06-05"}, expected: true},                                           //nolint:lll
+		{name: "Overdue", config: &configuration.Configuration{Planned: []string{"due"}}, task: task{DueOn: "2019-05-31"}, expected: true},                                            //nolint:lll
+		{name: "DueTomorrow", config: &configuration.Configuration{Planned: []string{"due"}}, task: task{DueOn: "2019-06-06"}, expected: false},                                       //nolint:lll
+		{name: "DueWithin", config: &configuration.Configuration{Planned: []string{"due"}, DueWithin: 2}, task: task{DueOn: "2019-06-07"}, expected: true},                            //nolint:lll
+		{name: "NoDueDate", config: &configuration.Configuration{Planned: []string{"due"}}, task: task{}, expected: false},                                                            //nolint:lll
+		{name: "Started", config: &configuration.Configuration{Planned: []string{"started"}}, task: task{StartOn: "2019-06-05"}, expected: true},                                      //nolint:lll
+		{name: "NotStarted", config: &configuration.Configuration{Planned: []string{"started"}}, task: task{StartOn: "2019-06-06"}, expected: false},                                  //nolint:lll
+		{name: "DefaultSection", config: &configuration.Configuration{Planned: []string{"section"}}, task: task{Section: "in progress"}, expected: true},                              //nolint:lll
+		{name: "OtherSection", config: &configuration.Configuration{Planned: []string{"section"}}, task: task{Section: "Backlog"}, expected: false},                                   //nolint:lll
+		{name: "Sections", config: &configuration.Configuration{Planned: []string{"section"}, PlannedSections: []string{"Doing*"}}, task: task{Section: "Doing now"}, expected: true}, //nolint:lll
+		{name: "AnyPolicy", config: &configuration.Configuration{Planned: []string{"due", "started"}}, task: task{StartOn: "2019-06-01"}, expected: true},                             //nolint:lll
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			tc.config.TodayMidnight = today
+			filter, err := newPlannedFilter(tc.config)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expected, filter.matches(tc.task))
+		})
+	}
+	location, err := time.LoadLocation("America/Los_Angeles")
+	assert.Nil(t, err)
+	todayInLocation := time.Date(2019, time.June, 5, 0, 0, 0, 0, location)
+	config := &configuration.Configuration{Planned: []string{"due"}, TodayMidnight: todayInLocation}
+	filter, err := newPlannedFilter(config)
+	assert.Nil(t, err)
+	assert.True(t, filter.matches(task{DueOn: "2019-06-06", DueAt: &dueAt}))
+}
+
+func TestProjectFilterApplyTasks(t *testing.T) {
+	tasks := []task{
+		{Name: "Task 1", Section: "In Progress"},
+		{Name: "Task 2", Section: "Backlog"},
+		{Name: "Task 3"},
+	}
+	testCases := []struct {
+		name     string
+		config   *configuration.Configuration
+		expected []task
+	}{
+		{name: "NoFilters", config: &configuration.Configuration{}, expected: tasks},
+		{name: "Sections", config: &configuration.Configuration{Sections: []string{"in *"}}, expected: tasks[:1]},
+		{name: "ExcludeSections", config: &configuration.Configuration{ExcludeSections: []string{"Backlog"}}, expected: []task{tasks[0], tasks[2]}}, //nolint:lll
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			filter, err := newProjectFilter(tc.config)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expected, filter.applyTasks(tasks))
+		})
+	}
+}
+
+func TestPlannedItemsBlocked(t *testing.T) {
+	planned, err := newPlannedFilter(&configuration.Configuration{Planned: []string{"due"}})
+	assert.Nil(t, err)
+	blockers, err := newBlockerFilter(&configuration.Configuration{BlockedSections: []string{"Blocked*"}})
+	assert.Nil(t, err)
+	tasks := []task{
+		{Name: "Task 1", Section: "Blocked on review", Dependencies: []dependency{{Name: "Task 4"}}},
+		{Name: "Task 2", Section: "Backlog"},
+		{Name: "Task 3", Section: "Blocked", Completed: true},
+	}
+	expected := []source.Item{
+		{Source: Name, Section: "Blocked on review", Name: "Task 1", Blocked: true, BlockedBy: []string{"Task 4"}},
+	}
+	assert.Equal(t, expected, plannedItems(tasks, planned, blockers))
+}
+
+func TestNewBlockerFilterInvalid(t *testing.T) {
+	testCases := []struct {
+		name   string
+		config *configuration.Configuration
+	}{
+		{name: "Sections", config: &configuration.Configuration{BlockedSections: []string{"/(/"}}},
+		{name: "Tags", config: &configuration.Configuration{BlockedTags: []string{"/(/"}}},
+		{name: "FieldWithoutValue", config: &configuration.Configuration{BlockedFields: []string{"Status"}}},
+		{name: "FieldName", config: &configuration.Configuration{BlockedFields: []string{"/(/=Blocked"}}},
+		{name: "FieldValue", config: &configuration.Configuration{BlockedFields: []string{"Status=/(/"}}},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := newBlockerFilter(tc.config)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestBlockerFilterMatches(t *testing.T) {
+	blocked := &entry{Gid: "1", Name: "Blocked"}
+	testCases := []struct {
+		name     string
+		config   *configuration.Configuration
+		task     task
+		expected bool
+	}{
+		{name: "NoRules", config: &configuration.Configuration{}, task: task{Section: "Blocked"}, expected: false},
+		{name: "Section", config: &configuration.Configuration{BlockedSections: []string{"blocked"}}, task: task{Section: "Blocked"}, expected: true},                                                        //nolint:lll
+		{name: "Tag", config: &configuration.Configuration{BlockedTags: []string{"blocked"}}, task: task{Tags: []entry{{Name: "blocked"}}}, expected: true},                                                  //nolint:lll
+		{name: "OtherTag", config: &configuration.Configuration{BlockedTags: []string{"blocked"}}, task: task{Tags: []entry{{Name: "urgent"}}}, expected: false},                                             //nolint:lll
+		{name: "Field", config: &configuration.Configuration{BlockedFields: []string{"Status=Blocked"}}, task: task{CustomFields: []customField{{Name: "Status", EnumValue: blocked}}}, expected: true},      //nolint:lll
+		{name: "OtherField", config: &configuration.Configuration{BlockedFields: []string{"Status=Blocked"}}, task: task{CustomFields: []customField{{Name: "Stage", EnumValue: blocked}}}, expected: false}, //nolint:lll
+		{name: "EmptyField", config: &configuration.Configuration{BlockedFields: []string{"Status=Blocked"}}, task: task{CustomFields: []customField{{Name: "Status"}}}, expected: false},                    //nolint:lll
+		{name: "OpenDependency", config: &configuration.Configuration{BlockedByDeps: true}, task: task{Dependencies: []dependency{{Name: "Task 1"}}}, expected: true},                                        //nolint:lll
+		{name: "CompletedDependency", config: &configuration.Configuration{BlockedByDeps: true}, task: task{Dependencies: []dependency{{Name: "Task 1", Completed: true}}}, expected: false},                 //nolint:lll
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			filter, err := newBlockerFilter(tc.config)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expected, filter.matches(tc.task))
+		})
+	}
+}
+
+func TestCompilePattern(t *testing.T) {
+	testCases := []struct {
+		name     string
+		pattern  string
+		input    string
+		expected bool
+	}{
+		{name: "Exact", pattern: "Project 1", input: "Project 1", expected: true},
+		{name: "CaseInsensitive", pattern: "project 1", input: "Project 1", expected: true},
+		{name: "WholeName", pattern: "Project", input: "Project 1", expected: false},
+		{name: "Star", pattern: "Pro*1", input: "Project / 1", expected: true},
+		{name: "QuestionMark", pattern: "Project ?", input: "Project 1", expected: true},
+		{name: "Metacharacters", pattern: "Project (1)", input: "Project (1)", expected: true},
+		{name: "Regex", pattern: "/^Proj/", input: "Project 1", expected: true},
+		{name: "RegexCaseSensitive", pattern: "/^proj/", input: "Project 1", expected: false},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			re, err := compilePattern(tc.pattern)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expected, re.MatchString(tc.input))
+		})
+	}
+}
+
 func TestAllTasksOneProjectNoTasks(t *testing.T) {
 	setup()
 	defer teardown()
 	now := time.Now().Local()
 	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
-	var wg sync.WaitGroup
-	conf := &configuration.Configuration{
-		TodayMidnight: midnight,
-		EarliestDate:  midnight.AddDate(0, 0, -1).Format(time.RFC3339),
-		WG:            &wg,
-	}
 	projectGIDs := []string{"1"}
 	pattern := fmt.Sprintf("/projects/%s/tasks", projectGIDs[0])
 	mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
 		fmt.Fprint(w, `{"data":[]}`)
 	})
-	actualTasks := cl.allTasks(projectGIDs, conf)
+	actualTasks, err := cl.allTasks(context.Background(), projectGIDs, midnight.AddDate(0, 0, -1))
+	assert.Nil(t, err)
 	var expectedTasks []task
 	assert.Equal(t, expectedTasks, actualTasks)
 }
@@ -189,12 +785,6 @@ func TestAllTasksOneProjectSomeTasks(t *testing.T) {
 	defer teardown()
 	now := time.Now().Local()
 	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
-	var wg sync.WaitGroup
-	conf := &configuration.Configuration{
-		TodayMidnight: midnight,
-		EarliestDate:  midnight.AddDate(0, 0, -1).Format(time.RFC3339),
-		WG:            &wg,
-	}
 	projectGIDs := []string{"1"}
 	pattern := fmt.Sprintf("/projects/%s/tasks", projectGIDs[0])
 	completedAt := time.Date(now.Year(), now.Month(), now.Day()-1, 12, 0, 0, 0, time.Local)
@@ -203,24 +793,82 @@ func TestAllTasksOneProjectSomeTasks(t *testing.T) {
 			{"completed":true,"completed_at":"%s","name":"Task 1"}
 		]}`, completedAt.Format(time.RFC3339))
 	})
-	actualTasks := cl.allTasks(projectGIDs, conf)
+	actualTasks, err := cl.allTasks(context.Background(), projectGIDs, midnight.AddDate(0, 0, -1))
+	assert.Nil(t, err)
 	expectedTasks := []task{
 		{Completed: true, CompletedAt: completedAt, Name: "Task 1"},
 	}
 	assert.Equal(t, expectedTasks, actualTasks)
 }
 
-func TestAllTasksOneProjectEmptyTask(t *testing.T) {
+func TestAllTasksProjectAndPermalink(t *testing.T) {
 	setup()
 	defer teardown()
 	now := time.Now().Local()
 	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
-	var wg sync.WaitGroup
-	conf := &configuration.Configuration{
-		TodayMidnight: midnight,
-		EarliestDate:  midnight.AddDate(0, 0, -1).Format(time.RFC3339),
-		WG:            &wg,
+	projectGIDs := []string{"1"}
+	pattern := fmt.Sprintf("/projects/%s/tasks", projectGIDs[0])
+	mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":[
+			{"completed":false,"name":"Task 1","permalink_url":"https://app.asana.com/0/1/2",
+			 "created_at":"2019-06-01T12:00:00Z","due_on":"2019-06-07","tags":[{"gid":"6","name":"Tag 1"}],
+			 "projects":[{"gid":"3","name":"Project 3"},{"gid":"1","name":"Project 1"}],
+			 "memberships":[{"project":{"gid":"3"},"section":{"gid":"4","name":"Backlog"}},
+			                {"project":{"gid":"1"},"section":{"gid":"5","name":"In Progress"}}]}
+		]}`)
+	})
+	actualTasks, err := cl.allTasks(context.Background(), projectGIDs, midnight.AddDate(0, 0, -1))
+	assert.Nil(t, err)
+	createdAt := time.Date(2019, time.June, 1, 12, 0, 0, 0, time.UTC)
+	expectedTasks := []task{
+		{
+			Name:         "Task 1",
+			PermalinkURL: "https://app.asana.com/0/1/2",
+			CreatedAt:    createdAt,
+			DueOn:        "2019-06-07",
+			Tags:         []entry{{Gid: "6", Name: "Tag 1"}},
+			Projects:     []entry{{Gid: "3", Name: "Project 3"}, {Gid: "1", Name: "Project 1"}},
+			Memberships: []membership{
+				{Project: entry{Gid: "3"}, Section: &entry{Gid: "4", Name: "Backlog"}},
+				{Project: entry{Gid: "1"}, Section: &entry{Gid: "5", Name: "In Progress"}},
+			},
+			Project: "Project 1",
+			Section: "In Progress",
+		},
+	}
+	assert.Equal(t, expectedTasks, actualTasks)
+	expectedItem := source.Item{
+		Source:    Name,
+		Project:   "Project 1",
+		Section:   "In Progress",
+		Tags:      []string{"Tag 1"},
+		Name:      "Task 1",
+		URL:       "https://app.asana.com/0/1/2",
+		CreatedAt: createdAt,
+		Due:       time.Date(2019, time.June, 7, 0, 0, 0, 0, time.Local),
 	}
+	assert.Equal(t, expectedItem, actualTasks[0].item(time.Local))
+}
+
+func TestAllTasksPositiveOffset(t *testing.T) {
+	setup()
+	defer teardown()
+	since := time.Date(2019, time.June, 3, 0, 0, 0, 0, time.FixedZone("CEST", 2*60*60))
+	mux.HandleFunc("/projects/1/tasks", func(w http.ResponseWriter, r *http.Request) {
+		completedSince, err := time.Parse(time.RFC3339, r.URL.Query().Get("completed_since"))
+		assert.Nil(t, err)
+		assert.True(t, since.Equal(completedSince))
+		fmt.Fprint(w, `{"data":[]}`)
+	})
+	_, err := cl.allTasks(context.Background(), []string{"1"}, since)
+	assert.Nil(t, err)
+}
+
+func TestAllTasksOneProjectEmptyTask(t *testing.T) {
+	setup()
+	defer teardown()
+	now := time.Now().Local()
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
 	projectGIDs := []string{"1"}
 	pattern := fmt.Sprintf("/projects/%s/tasks", projectGIDs[0])
 	completedAt := time.Date(now.Year(), now.Month(), now.Day()-1, 12, 0, 0, 0, time.Local)
@@ -229,7 +877,8 @@ func TestAllTasksOneProjectEmptyTask(t *testing.T) {
 			{"completed":true,"completed_at":"%s","name":""}
 		]}`, completedAt.Format(time.RFC3339))
 	})
-	actualTasks := cl.allTasks(projectGIDs, conf)
+	actualTasks, err := cl.allTasks(context.Background(), projectGIDs, midnight.AddDate(0, 0, -1))
+	assert.Nil(t, err)
 	var expectedTasks []task
 	assert.Equal(t, expectedTasks, actualTasks)
 }
@@ -239,12 +888,6 @@ func TestAllTasksOneProjectEmptyTaskNonEmptyTask(t *testing.T) {
 	defer teardown()
 	now := time.Now().Local()
 	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
-	var wg sync.WaitGroup
-	conf := &configuration.Configuration{
-		TodayMidnight: midnight,
-		EarliestDate:  midnight.AddDate(0, 0, -1).Format(time.RFC3339),
-		WG:            &wg,
-	}
 	projectGIDs := []string{"1"}
 	pattern := fmt.Sprintf("/projects/%s/tasks", projectGIDs[0])
 	completedAt := time.Date(now.Year(), now.Month(), now.Day()-1, 12, 0, 0, 0, time.Local)
@@ -254,9 +897,62 @@ func TestAllTasksOneProjectEmptyTaskNonEmptyTask(t *testing.T) {
 			{"completed":true,"completed_at":"%s","name":"Task 1"}
 		]}`, completedAt.Format(time.RFC3339), completedAt.Format(time.RFC3339))
 	})
-	actualTasks := cl.allTasks(projectGIDs, conf)
+	actualTasks, err := cl.allTasks(context.Background(), projectGIDs, midnight.AddDate(0, 0, -1))
+	assert.Nil(t, err)
+	expectedTasks := []task{
+		{Completed: true, CompletedAt: completedAt, Name: "Task 1"},
+	}
+	assert.Equal(t, expectedTasks, actualTasks)
+}
+
+func TestAllTasksOneProjectMultiplePages(t *testing.T) {
+	setup()
+	defer teardown()
+	now := time.Now().Local()
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	projectGIDs := []string{"1"}
+	pattern := fmt.Sprintf("/projects/%s/tasks", projectGIDs[0])
+	completedAt := decodedTime(time.Date(now.Year(), now.Month(), now.Day()-1, 12, 0, 0, 0, time.Local))
+	mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("offset") == "" {
+			fmt.Fprintf(w, `{"data":[
+				{"completed":true,"completed_at":"%s","name":"Task 1"}
+			],"next_page":{"offset":"abc"}}`, completedAt.Format(time.RFC3339))
+			return
+		}
+		fmt.Fprintf(w, `{"data":[
+			{"completed":true,"completed_at":"%s","name":"Task 2"}
+		],"next_page":null}`, completedAt.Format(time.RFC3339))
+	})
+	actualTasks, err := cl.allTasks(context.Background(), projectGIDs, midnight.AddDate(0, 0, -1))
+	assert.Nil(t, err)
 	expectedTasks := []task{
 		{Completed: true, CompletedAt: completedAt, Name: "Task 1"},
+		{Completed: true, CompletedAt: completedAt, Name: "Task 2"},
+	}
+	assert.Equal(t, expectedTasks, actualTasks)
+}
+
+func TestAllTasksOneProjectAssignedTasks(t *testing.T) {
+	setup()
+	defer teardown()
+	cl.assigneeGID = "10"
+	now := time.Now().Local()
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	projectGIDs := []string{"1"}
+	pattern := fmt.Sprintf("/projects/%s/tasks", projectGIDs[0])
+	completedAt := decodedTime(time.Date(now.Year(), now.Month(), now.Day()-1, 12, 0, 0, 0, time.Local))
+	mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"data":[
+			{"assignee":{"gid":"10"},"completed":true,"completed_at":"%s","name":"Task 1"},
+			{"assignee":{"gid":"11"},"completed":true,"completed_at":"%s","name":"Task 2"},
+			{"assignee":null,"completed":false,"name":"Task 3"}
+		]}`, completedAt.Format(time.RFC3339), completedAt.Format(time.RFC3339))
+	})
+	actualTasks, err := cl.allTasks(context.Background(), projectGIDs, midnight.AddDate(0, 0, -1))
+	assert.Nil(t, err)
+	expectedTasks := []task{
+		{Assignee: &entry{Gid: "10"}, Completed: true, CompletedAt: completedAt, Name: "Task 1"},
 	}
 	assert.Equal(t, expectedTasks, actualTasks)
 }
@@ -265,33 +961,14 @@ func TestAllTasksOneProjectFailure(t *testing.T) {
 	setup()
 	defer teardown()
 	assert := assert.New(t)
-	oldStdout := os.Stdout
-	r, w, _ := os.Pipe() //nolint:errcheck
-	os.Stdout = w
 	now := time.Now().Local()
 	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
-	var wg sync.WaitGroup
-	conf := &configuration.Configuration{
-		TodayMidnight: midnight,
-		EarliestDate:  midnight.AddDate(0, 0, -1).Format(time.RFC3339),
-		WG:            &wg,
-	}
 	projectGIDs := []string{"1"}
-	actualTasks := cl.allTasks(projectGIDs, conf)
-	outputChan := make(chan string)
-	// copy the output in a separate goroutine so printing can't block indefinitely
-	go func() {
-		var buf bytes.Buffer
-		io.Copy(&buf, r) //nolint:errcheck
-		outputChan <- buf.String()
-	}()
-	w.Close()
-	os.Stdout = oldStdout
-	actualOutput := <-outputChan
+	actualTasks, err := cl.allTasks(context.Background(), projectGIDs, midnight.AddDate(0, 0, -1))
 	var expectedTasks []task
 	assert.Equal(expectedTasks, actualTasks)
-	const expectedOutput = "error requesting tasks for project 1"
-	assert.Contains(actualOutput, expectedOutput)
+	const expectedError = "error requesting tasks for project 1"
+	assert.Contains(err.Error(), expectedError)
 }
 
 func TestAllTasksMultipleProjectsAllTasks(t *testing.T) {
@@ -299,12 +976,7 @@ func TestAllTasksMultipleProjectsAllTasks(t *testing.T) {
 	defer teardown()
 	now := time.Now().Local()
 	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
-	var wg sync.WaitGroup
-	conf := &configuration.Configuration{
-		TodayMidnight: midnight,
-		EarliestDate:  midnight.AddDate(0, 0, -1).Format(time.RFC3339),
-		WG:            &wg,
-	}
+	cl.concurrency = 2
 	projectGIDs := []string{"1", "2"}
 	completedAt := time.Date(now.Year(), now.Month(), now.Day()-1, 12, 0, 0, 0, time.Local)
 	pattern1 := fmt.Sprintf("/projects/%s/tasks", projectGIDs[0])
@@ -319,7 +991,8 @@ func TestAllTasksMultipleProjectsAllTasks(t *testing.T) {
 			{"completed":true,"completed_at":"%s","name":"Task 2"}
 		]}`, completedAt.Format(time.RFC3339))
 	})
-	actualTasks := cl.allTasks(projectGIDs, conf)
+	actualTasks, err := cl.allTasks(context.Background(), projectGIDs, midnight.AddDate(0, 0, -1))
+	assert.Nil(t, err)
 	expectedTasks := []task{
 		{Completed: true, CompletedAt: completedAt, Name: "Task 1"},
 		{Completed: true, CompletedAt: completedAt, Name: "Task 2"},
@@ -332,12 +1005,7 @@ func TestAllTasksMultipleProjectsSomeTasksSomeNone(t *testing.T) {
 	defer teardown()
 	now := time.Now().Local()
 	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
-	var wg sync.WaitGroup
-	conf := &configuration.Configuration{
-		TodayMidnight: midnight,
-		EarliestDate:  midnight.AddDate(0, 0, -1).Format(time.RFC3339),
-		WG:            &wg,
-	}
+	cl.concurrency = 2
 	projectGIDs := []string{"1", "2"}
 	completedAt := time.Date(now.Year(), now.Month(), now.Day()-1, 12, 0, 0, 0, time.Local)
 	pattern1 := fmt.Sprintf("/projects/%s/tasks", projectGIDs[0])
@@ -350,7 +1018,8 @@ func TestAllTasksMultipleProjectsSomeTasksSomeNone(t *testing.T) {
 	mux.HandleFunc(pattern2, func(w http.ResponseWriter, r *http.Request) {
 		fmt.Fprint(w, `{"data":[]}`)
 	})
-	actualTasks := cl.allTasks(projectGIDs, conf)
+	actualTasks, err := cl.allTasks(context.Background(), projectGIDs, midnight.AddDate(0, 0, -1))
+	assert.Nil(t, err)
 	expectedTasks := []task{
 		{Completed: true, CompletedAt: completedAt, Name: "Task 1"},
 	}
@@ -361,17 +1030,9 @@ func TestAllTasksMultipleProjectsSomeTasksSomeError(t *testing.T) {
 	setup()
 	defer teardown()
 	assert := assert.New(t)
-	oldStdout := os.Stdout
-	r, w, _ := os.Pipe() //nolint:errcheck
-	os.Stdout = w
 	now := time.Now().Local()
 	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
-	var wg sync.WaitGroup
-	conf := &configuration.Configuration{
-		TodayMidnight: midnight,
-		EarliestDate:  midnight.AddDate(0, 0, -1).Format(time.RFC3339),
-		WG:            &wg,
-	}
+	cl.concurrency = 2
 	projectGIDs := []string{"1", "2"}
 	completedAt := time.Date(now.Year(), now.Month(), now.Day()-1, 12, 0, 0, 0, time.Local)
 	pattern := fmt.Sprintf("/projects/%s/tasks", projectGIDs[0])
@@ -380,127 +1041,622 @@ func TestAllTasksMultipleProjectsSomeTasksSomeError(t *testing.T) {
 			{"completed":true,"completed_at":"%s","name":"Task 1"}
 		]}`, completedAt.Format(time.RFC3339))
 	})
-	actualTasks := cl.allTasks(projectGIDs, conf)
-	outputChan := make(chan string)
-	// copy the output in a separate goroutine so printing can't block indefinitely
-	go func() {
-		var buf bytes.Buffer
-		io.Copy(&buf, r) //nolint:errcheck
-		outputChan <- buf.String()
-	}()
-	w.Close()
-	os.Stdout = oldStdout
-	actualOutput := <-outputChan
+	actualTasks, err := cl.allTasks(context.Background(), projectGIDs, midnight.AddDate(0, 0, -1))
 	expectedTasks := []task{
 		{Completed: true, CompletedAt: completedAt, Name: "Task 1"},
 	}
 	assert.ElementsMatch(expectedTasks, actualTasks)
-	const expectedOutput = "error requesting tasks for project 2"
-	assert.Contains(actualOutput, expectedOutput)
+	const expectedError = "error requesting tasks for project 2"
+	assert.Contains(err.Error(), expectedError)
 }
 
 func TestAllTasksMultipleProjectsSomeNoneSomeError(t *testing.T) {
 	setup()
 	defer teardown()
 	assert := assert.New(t)
-	oldStdout := os.Stdout
-	r, w, _ := os.Pipe() //nolint:errcheck
-	os.Stdout = w
 	now := time.Now().Local()
 	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
-	var wg sync.WaitGroup
-	conf := &configuration.Configuration{
-		TodayMidnight: midnight,
-		EarliestDate:  midnight.AddDate(0, 0, -1).Format(time.RFC3339),
-		WG:            &wg,
-	}
+	cl.concurrency = 2
 	projectGIDs := []string{"1", "2"}
 	pattern := fmt.Sprintf("/projects/%s/tasks", projectGIDs[0])
 	mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
 		fmt.Fprintf(w, `{"data":[]}`)
 	})
-	actualTasks := cl.allTasks(projectGIDs, conf)
-	outputChan := make(chan string)
-	// copy the output in a separate goroutine so printing can't block indefinitely
-	go func() {
-		var buf bytes.Buffer
-		io.Copy(&buf, r) //nolint:errcheck
-		outputChan <- buf.String()
-	}()
-	w.Close()
-	os.Stdout = oldStdout
-	actualOutput := <-outputChan
+	actualTasks, err := cl.allTasks(context.Background(), projectGIDs, midnight.AddDate(0, 0, -1))
 	var expectedTasks []task
 	assert.ElementsMatch(expectedTasks, actualTasks)
-	const expectedOutput = "error requesting tasks for project 2"
-	assert.Contains(actualOutput, expectedOutput)
+	const expectedError = "error requesting tasks for project 2"
+	assert.Contains(err.Error(), expectedError)
 }
 
 func TestAllTasksMultipleProjectsAllError(t *testing.T) {
 	setup()
 	defer teardown()
 	assert := assert.New(t)
-	oldStdout := os.Stdout
-	r, w, _ := os.Pipe() //nolint:errcheck
-	os.Stdout = w
 	now := time.Now().Local()
 	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
-	var wg sync.WaitGroup
-	conf := &configuration.Configuration{
-		TodayMidnight: midnight,
-		EarliestDate:  midnight.AddDate(0, 0, -1).Format(time.RFC3339),
-		WG:            &wg,
-	}
+	cl.concurrency = 2
 	projectGIDs := []string{"1", "2"}
-	actualTasks := cl.allTasks(projectGIDs, conf)
-	outputChan := make(chan string)
-	// copy the output in a separate goroutine so printing can't block indefinitely
-	go func() {
-		var buf bytes.Buffer
-		io.Copy(&buf, r) //nolint:errcheck
-		outputChan <- buf.String()
-	}()
-	w.Close()
-	os.Stdout = oldStdout
-	actualOutput := <-outputChan
+	actualTasks, err := cl.allTasks(context.Background(), projectGIDs, midnight.AddDate(0, 0, -1))
 	var expectedTasks []task
 	assert.ElementsMatch(expectedTasks, actualTasks)
-	const expectedOutput2 = "error requesting tasks for project 2"
-	assert.Contains(actualOutput, expectedOutput2)
+	const expectedError = "error requesting tasks for project 2"
+	assert.Contains(err.Error(), expectedError)
+}
+
+func TestAllTasksConcurrencyLimit(t *testing.T) {
+	setup()
+	defer teardown()
+	assert := assert.New(t)
+	now := time.Now().Local()
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	cl.concurrency = 2
+	projectGIDs := []string{"1", "2", "3", "4", "5"}
+	var mu sync.Mutex
+	inFlight, maxInFlight := 0, 0
+	for _, projectGID := range projectGIDs {
+		pattern := fmt.Sprintf("/projects/%s/tasks", projectGID)
+		name := fmt.Sprintf("Task %s", projectGID)
+		mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			inFlight++
+			if inFlight > maxInFlight {
+				maxInFlight = inFlight
+			}
+			mu.Unlock()
+			time.Sleep(time.Millisecond * 10)
+			mu.Lock()
+			inFlight--
+			mu.Unlock()
+			fmt.Fprintf(w, `{"data":[{"completed":false,"name":"%s"}]}`, name)
+		})
+	}
+	actualTasks, err := cl.allTasks(context.Background(), projectGIDs, midnight.AddDate(0, 0, -1))
+	assert.Nil(err)
+	assert.Len(actualTasks, len(projectGIDs))
+	assert.Equal(2, maxInFlight)
+}
+
+func TestAllTasksCanceledContext(t *testing.T) {
+	setup()
+	defer teardown()
+	assert := assert.New(t)
+	now := time.Now().Local()
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	cl.concurrency = 1
+	projectGIDs := []string{"1", "2", "3"}
+	ctx, cancel := context.WithCancel(context.Background())
+	requests := 0
+	mux.HandleFunc("/projects/1/tasks", func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		cancel()
+		fmt.Fprint(w, `{"data":[]}`)
+	})
+	_, err := cl.allTasks(ctx, projectGIDs, midnight.AddDate(0, 0, -1))
+	assert.True(xerrors.Is(err, context.Canceled))
+	assert.Equal(1, requests)
+}
+
+func TestAddSubtasks(t *testing.T) {
+	setup()
+	defer teardown()
+	assert := assert.New(t)
+	cl.concurrency = 2
+	cl.assigneeGID = "10"
+	mux.HandleFunc("/tasks/1/subtasks", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":[
+			{"completed":true,"name":"Subtask 1","assignee":{"gid":"10"}},
+			{"completed":false,"name":"Subtask 2"},
+			{"completed":false,"name":"Subtask 3","assignee":{"gid":"11"}},
+			{"completed":false,"name":""}
+		]}`)
+	})
+	mux.HandleFunc("/tasks/2/subtasks", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("subtasks requested for a task without subtasks")
+	})
+	tasks := []task{
+		{Gid: "1", Name: "Task 1", NumSubtasks: 4},
+		{Gid: "2", Name: "Task 2"},
+	}
+	tasks, err := cl.addSubtasks(context.Background(), tasks)
+	assert.Nil(err)
+	expectedSubtasks := []task{
+		{Completed: true, Name: "Subtask 1", Assignee: &entry{Gid: "10"}},
+		{Completed: false, Name: "Subtask 2"},
+	}
+	assert.Equal(expectedSubtasks, tasks[0].Subtasks)
+	assert.Nil(tasks[1].Subtasks)
+}
+
+func TestAddSubtasksOtherAssignee(t *testing.T) {
+	setup()
+	defer teardown()
+	assert := assert.New(t)
+	cl.assigneeGID = "10"
+	mux.HandleFunc("/tasks/1/subtasks", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":[
+			{"completed":true,"name":"Subtask 1","assignee":{"gid":"10"}},
+			{"completed":false,"name":"Subtask 2"},
+			{"completed":false,"name":"Subtask 3","assignee":{"gid":"11"}}
+		]}`)
+	})
+	mux.HandleFunc("/tasks/2/subtasks", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":[{"completed":true,"name":"Subtask 4"}]}`)
+	})
+	tasks := []task{
+		{Gid: "1", Name: "Task 1", NumSubtasks: 3, ForSubtasks: true},
+		{Gid: "2", Name: "Task 2", NumSubtasks: 1, ForSubtasks: true},
+	}
+	tasks, err := cl.addSubtasks(context.Background(), tasks)
+	assert.Nil(err)
+	expectedTasks := []task{
+		{Gid: "1", Name: "Task 1", NumSubtasks: 3, ForSubtasks: true, Subtasks: []task{
+			{Completed: true, Name: "Subtask 1", Assignee: &entry{Gid: "10"}},
+		}},
+	}
+	assert.Equal(expectedTasks, tasks)
+}
+
+func TestAddSubtasksSomeError(t *testing.T) {
+	setup()
+	defer teardown()
+	assert := assert.New(t)
+	cl.concurrency = 2
+	mux.HandleFunc("/tasks/1/subtasks", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":[{"completed":false,"name":"Subtask 1"}]}`)
+	})
+	mux.HandleFunc("/tasks/2/subtasks", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	tasks := []task{
+		{Gid: "1", Name: "Task 1", NumSubtasks: 1},
+		{Gid: "2", Name: "Task 2", NumSubtasks: 1},
+	}
+	tasks, err := cl.addSubtasks(context.Background(), tasks)
+	assert.Contains(err.Error(), "error requesting subtasks of task 2")
+	assert.Equal([]task{{Name: "Subtask 1"}}, tasks[0].Subtasks)
+	assert.Nil(tasks[1].Subtasks)
+}
+
+func TestForEach(t *testing.T) {
+	assert := assert.New(t)
+	var mu sync.Mutex
+	called := make([]bool, 5)
+	errs := forEach(context.Background(), len(called), 2, func(i int) error {
+		mu.Lock()
+		defer mu.Unlock()
+		called[i] = true
+		if i%2 == 1 {
+			return xerrors.Errorf("error %d", i)
+		}
+		return nil
+	})
+	assert.Equal([]bool{true, true, true, true, true}, called)
+	assert.Len(errs, 2)
+}
+
+func TestWorkerCount(t *testing.T) {
+	testCases := []struct {
+		name         string
+		concurrency  int
+		projectCount int
+		expected     int
+	}{
+		{name: "Unset", concurrency: 0, projectCount: 5, expected: 1},
+		{name: "FewerProjects", concurrency: 4, projectCount: 2, expected: 2},
+		{name: "MoreProjects", concurrency: 4, projectCount: 10, expected: 4},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, workerCount(tc.concurrency, tc.projectCount))
+		})
+	}
+}
+
+func TestProjectErrorsError(t *testing.T) {
+	errs := projectErrors{xerrors.New("error 1"), xerrors.New("error 2")}
+	const expected = "error retrieving tasks for 2 project(s):\nerror 1\nerror 2"
+	assert.EqualError(t, errs, expected)
+}
+
+func TestAPIErrorError(t *testing.T) {
+	testCases := []struct {
+		name     string
+		err      *APIError
+		expected string
+	}{
+		{
+			name:     "StatusOnly",
+			err:      &APIError{StatusCode: http.StatusBadGateway},
+			expected: "Asana responded with 502 Bad Gateway",
+		},
+		{
+			name:     "Messages",
+			err:      &APIError{StatusCode: http.StatusNotFound, Messages: []string{"Unknown object", "Try again"}},
+			expected: "Asana responded with 404 Not Found: Unknown object; Try again",
+		},
+		{
+			name:     "MessagesHelp",
+			err:      &APIError{StatusCode: http.StatusUnauthorized, Messages: []string{"Not Authorized"}, Help: "Docs"},
+			expected: "Asana responded with 401 Unauthorized: Not Authorized (Docs)",
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			assert.EqualError(t, tc.err, tc.expected)
+		})
+	}
+}
+
+func TestExplain(t *testing.T) {
+	testCases := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{name: "Unauthorized", err: &APIError{StatusCode: http.StatusUnauthorized}, expected: "token rejected"},
+		{name: "Forbidden", err: &APIError{StatusCode: http.StatusForbidden}, expected: "access to project 1 denied"},
+		{name: "NotFound", err: &APIError{StatusCode: http.StatusNotFound}, expected: "project 1 not found"},
+		{name: "TooManyRequests", err: &APIError{StatusCode: http.StatusTooManyRequests}, expected: "rate limited"},
+		{name: "ServerError", err: &APIError{StatusCode: http.StatusServiceUnavailable}, expected: "Asana is unavailable"},
+		{name: "Wrapped", err: xerrors.Errorf("wrap: %w", &APIError{StatusCode: http.StatusNotFound}), expected: "not found"},
+		{name: "Other", err: xerrors.New("other error"), expected: "other error"},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			actual := explain(tc.err, "project 1")
+			assert.Contains(t, actual.Error(), tc.expected)
+			assert.True(t, xerrors.Is(actual, tc.err))
+		})
+	}
+}
+
+func TestRetryPolicyConfigure(t *testing.T) {
+	assert := assert.New(t)
+	policy := defaultRetryPolicy()
+	policy.configure(&configuration.Configuration{})
+	assert.Equal(defaultRetryPolicy(), policy)
+	policy.configure(&configuration.Configuration{MaxAttempts: 2, MaxRetryWait: time.Second})
+	assert.Equal(2, policy.maxAttempts)
+	assert.Equal(time.Second, policy.maxWait)
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	policy := retryPolicy{maxAttempts: 10, baseDelay: time.Second, maxWait: time.Second * 5}
+	testCases := []struct {
+		name       string
+		attempt    int
+		retryAfter time.Duration
+		min        time.Duration
+		max        time.Duration
+	}{
+		{name: "FirstRetry", attempt: 1, min: time.Millisecond * 500, max: time.Second},
+		{name: "SecondRetry", attempt: 2, min: time.Second, max: time.Second * 2},
+		{name: "Capped", attempt: 8, min: time.Millisecond * 2500, max: time.Second * 5},
+		{name: "Overflow", attempt: 100, min: time.Millisecond * 2500, max: time.Second * 5},
+		{name: "RetryAfter", attempt: 1, retryAfter: time.Second * 3, min: time.Second * 3, max: time.Second * 3},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			actual := policy.delay(tc.attempt, tc.retryAfter)
+			assert.True(t, actual >= tc.min && actual <= tc.max, "delay %s not in [%s, %s]", actual, tc.min, tc.max)
+		})
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	testCases := []struct {
+		name     string
+		ctx      context.Context
+		err      error
+		expected bool
+	}{
+		{name: "TooManyRequests", ctx: context.Background(), err: &APIError{StatusCode: 429}, expected: true},
+		{name: "ServerError", ctx: context.Background(), err: &APIError{StatusCode: 503}, expected: true},
+		{name: "ClientError", ctx: context.Background(), err: &APIError{StatusCode: 404}, expected: false},
+		{name: "NetworkError", ctx: context.Background(), err: &url.Error{Err: io.EOF}, expected: true},
+		{name: "OtherError", ctx: context.Background(), err: xerrors.New("decode error"), expected: false},
+		{name: "ContextDone", ctx: canceled, err: &APIError{StatusCode: 503}, expected: false},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, retryable(tc.ctx, tc.err))
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2019, 7, 18, 12, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		name     string
+		header   string
+		expected time.Duration
+	}{
+		{name: "Missing", header: "", expected: 0},
+		{name: "Seconds", header: "30", expected: time.Second * 30},
+		{name: "NegativeSeconds", header: "-1", expected: 0},
+		{name: "Date", header: "Thu, 18 Jul 2019 12:01:00 GMT", expected: time.Minute},
+		{name: "PastDate", header: "Thu, 18 Jul 2019 11:00:00 GMT", expected: 0},
+		{name: "Invalid", header: "soon", expected: 0},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, parseRetryAfter(tc.header, now))
+		})
+	}
+}
+
+func TestFilterAssignedTasks(t *testing.T) {
+	tasks := []task{
+		{Assignee: &entry{Gid: "1"}, Name: "Task 1"},
+		{Assignee: &entry{Gid: "2"}, Name: "Task 2"},
+		{Assignee: nil, Name: "Task 3"},
+	}
+	actualTasks := filterAssignedTasks(tasks, "1", false)
+	expectedTasks := []task{
+		{Assignee: &entry{Gid: "1"}, Name: "Task 1"},
+	}
+	assert.Equal(t, expectedTasks, actualTasks)
+}
+
+func TestFilterAssignedTasksSubtasks(t *testing.T) {
+	tasks := []task{
+		{Assignee: &entry{Gid: "1"}, Name: "Task 1", NumSubtasks: 1},
+		{Assignee: &entry{Gid: "2"}, Name: "Task 2", NumSubtasks: 1},
+		{Assignee: &entry{Gid: "2"}, Name: "Task 3"},
+	}
+	actualTasks := filterAssignedTasks(tasks, "1", true)
+	expectedTasks := []task{
+		{Assignee: &entry{Gid: "1"}, Name: "Task 1", NumSubtasks: 1},
+		{Assignee: &entry{Gid: "2"}, Name: "Task 2", NumSubtasks: 1, ForSubtasks: true},
+	}
+	assert.Equal(t, expectedTasks, actualTasks)
+}
+
+func newTestSource(config *configuration.Configuration) *Source {
+	filter, _ := newProjectFilter(config)   //nolint:errcheck
+	planned, _ := newPlannedFilter(config)  //nolint:errcheck
+	blockers, _ := newBlockerFilter(config) //nolint:errcheck
+	return &Source{client: cl, config: config, filter: filter, planned: planned, blockers: blockers}
+}
+
+func TestSourceCompletedAndPlanned(t *testing.T) {
+	setup()
+	defer teardown()
+	assert := assert.New(t)
+	now := time.Now().Local()
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	since := midnight.AddDate(0, 0, -1)
+	completedAt := decodedTime(time.Date(now.Year(), now.Month(), now.Day()-1, 12, 0, 0, 0, time.Local))
+	mux.HandleFunc("/users/me", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":{"gid":"10","name":"User 1"}}`)
+	})
+	mux.HandleFunc("/workspaces", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":[{"gid":"1","name":"Workspace 1"}]}`)
+	})
+	mux.HandleFunc("/workspaces/1/projects", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":[{"gid":"2","name":"Project 1"}]}`)
+	})
+	taskRequests := 0
+	mux.HandleFunc("/projects/2/tasks", func(w http.ResponseWriter, r *http.Request) {
+		taskRequests++
+		assert.Equal(since.UTC().Format(time.RFC3339), r.URL.Query().Get("completed_since"))
+		fmt.Fprintf(w, `{"data":[
+			{"assignee":{"gid":"10"},"completed":true,"completed_at":"%s","name":"Task 1"},
+			{"assignee":{"gid":"10"},"completed":false,"name":"Task 2"},
+			{"assignee":{"gid":"11"},"completed":false,"name":"Task 3"}
+		]}`, completedAt.Format(time.RFC3339))
+	})
+	s := newTestSource(&configuration.Configuration{})
+	completed, err := s.Completed(context.Background(), since, midnight)
+	assert.Nil(err)
+	expectedCompleted := []source.Item{
+		{Source: Name, Name: "Task 1", CompletedAt: completedAt},
+	}
+	assert.Equal(expectedCompleted, completed)
+	planned, err := s.Planned(context.Background())
+	assert.Nil(err)
+	expectedPlanned := []source.Item{
+		{Source: Name, Name: "Task 2"},
+	}
+	assert.Equal(expectedPlanned, planned)
+	assert.Equal(1, taskRequests)
+}
+
+func TestSourceCompletedOtherAssigneeSubtasks(t *testing.T) {
+	setup()
+	defer teardown()
+	assert := assert.New(t)
+	cl.withSubtasks = true
+	now := time.Now().Local()
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	since := midnight.AddDate(0, 0, -1)
+	completedAt := decodedTime(time.Date(now.Year(), now.Month(), now.Day()-1, 12, 0, 0, 0, time.Local))
+	mux.HandleFunc("/users/me", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":{"gid":"10","name":"User 1"}}`)
+	})
+	mux.HandleFunc("/workspaces", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":[{"gid":"1","name":"Workspace 1"}]}`)
+	})
+	mux.HandleFunc("/workspaces/1/projects", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":[{"gid":"2","name":"Project 1"}]}`)
+	})
+	mux.HandleFunc("/projects/2/tasks", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"data":[
+			{"gid":"3","assignee":{"gid":"11"},"completed":true,"completed_at":"%s","name":"Task 1","num_subtasks":2},
+			{"gid":"4","assignee":{"gid":"11"},"completed":false,"name":"Task 2","num_subtasks":1},
+			{"gid":"5","assignee":{"gid":"11"},"completed":false,"name":"Task 3"}
+		]}`, completedAt.Format(time.RFC3339))
+	})
+	mux.HandleFunc("/tasks/3/subtasks", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"data":[
+			{"assignee":{"gid":"10"},"completed":true,"completed_at":"%s","name":"Subtask 1"},
+			{"assignee":null,"completed":true,"completed_at":"%s","name":"Subtask 2"}
+		]}`, completedAt.Format(time.RFC3339), completedAt.Format(time.RFC3339))
+	})
+	mux.HandleFunc("/tasks/4/subtasks", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":[{"assignee":{"gid":"11"},"completed":false,"name":"Subtask 3"}]}`)
+	})
+	s := newTestSource(&configuration.Configuration{Subtasks: configuration.SubtasksNest})
+	completed, err := s.Completed(context.Background(), since, midnight)
+	assert.Nil(err)
+	expectedCompleted := []source.Item{
+		{
+			Source:       Name,
+			Name:         "Task 1",
+			Subtasks:     []source.Item{{Source: Name, Name: "Subtask 1", CompletedAt: completedAt}},
+			SubtaskCount: 1,
+			SubtasksDone: 1,
+		},
+	}
+	assert.Equal(expectedCompleted, completed)
+	planned, err := s.Planned(context.Background())
+	assert.Nil(err)
+	assert.Empty(planned)
+}
+
+func TestSourceActivity(t *testing.T) {
+	setup()
+	defer teardown()
+	assert := assert.New(t)
+	now := time.Now().Local()
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	since := midnight.AddDate(0, 0, -1)
+	createdAt1 := time.Date(now.Year(), now.Month(), now.Day()-1, 12, 0, 0, 0, time.Local)
+	createdAt2 := time.Date(now.Year(), now.Month(), now.Day()-1, 13, 0, 0, 0, time.Local)
+	before := time.Date(now.Year(), now.Month(), now.Day()-2, 12, 0, 0, 0, time.Local)
+	mux.HandleFunc("/users/me", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":{"gid":"10","name":"User 1"}}`)
+	})
+	mux.HandleFunc("/workspaces", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":[{"gid":"1","name":"Workspace 1"}]}`)
+	})
+	mux.HandleFunc("/workspaces/1/projects", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":[{"gid":"2","name":"Project 1"}]}`)
+	})
+	mux.HandleFunc("/projects/2/project_statuses", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"data":[
+			{"gid":"20","title":"On track","created_at":"%s","created_by":{"gid":"10"}},
+			{"gid":"21","title":"At risk","created_at":"%s","created_by":{"gid":"10"}},
+			{"gid":"22","title":"Off track","created_at":"%s","created_by":{"gid":"11"}}
+		]}`, createdAt2.Format(time.RFC3339), before.Format(time.RFC3339), createdAt2.Format(time.RFC3339))
+	})
+	mux.HandleFunc("/tasks", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal("2", r.URL.Query().Get("project"))
+		assert.Equal(since.UTC().Format(time.RFC3339), r.URL.Query().Get("modified_since"))
+		fmt.Fprint(w, `{"data":[{"gid":"5","name":"Task 1","permalink_url":"https://app.asana.com/0/2/5"}]}`)
+	})
+	mux.HandleFunc("/tasks/5/stories", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"data":[
+			{"gid":"30","resource_subtype":"comment_added","created_at":"%[1]s","created_by":{"gid":"10"}},
+			{"gid":"31","resource_subtype":"comment_added","created_at":"%[2]s","created_by":{"gid":"10"}},
+			{"gid":"32","resource_subtype":"attachment_added","created_at":"%[2]s","created_by":{"gid":"10"}},
+			{"gid":"33","resource_subtype":"assigned","created_at":"%[2]s","created_by":{"gid":"10"}},
+			{"gid":"34","resource_subtype":"comment_added","created_at":"%[3]s","created_by":{"gid":"10"}},
+			{"gid":"35","resource_subtype":"attachment_added","created_at":"%[1]s","created_by":{"gid":"11"}}
+		]}`, createdAt1.Format(time.RFC3339), createdAt2.Format(time.RFC3339), before.Format(time.RFC3339))
+	})
+	s := newTestSource(&configuration.Configuration{Activity: true})
+	activity, err := s.Activity(context.Background(), since, midnight)
+	assert.Nil(err)
+	expectedActivity := []source.Item{
+		{Source: Name, Project: "Project 1", Name: "Posted status update on Project 1: On track", CompletedAt: createdAt2},
+		{Source: Name, Project: "Project 1", Name: "Commented on Task 1", URL: "https://app.asana.com/0/2/5", CompletedAt: createdAt1},     //nolint:lll
+		{Source: Name, Project: "Project 1", Name: "Attached file to Task 1", URL: "https://app.asana.com/0/2/5", CompletedAt: createdAt2}, //nolint:lll
+	}
+	assert.Equal(expectedActivity, activity)
+}
+
+func TestSourceActivityDisabled(t *testing.T) {
+	setup()
+	defer teardown()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request for %s", r.URL.Path)
+	})
+	s := newTestSource(&configuration.Configuration{})
+	now := time.Now()
+	activity, err := s.Activity(context.Background(), now.AddDate(0, 0, -1), now)
+	assert.Nil(t, err)
+	assert.Empty(t, activity)
+}
+
+func TestSourceMultipleWorkspaces(t *testing.T) {
+	setup()
+	defer teardown()
+	assert := assert.New(t)
+	now := time.Now().Local()
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	mux.HandleFunc("/workspaces", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":[{"gid":"1","name":"Workspace 1"},{"gid":"2","name":"Workspace 2"}]}`)
+	})
+	mux.HandleFunc("/workspaces/1/projects", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":[{"gid":"3","name":"Project 1"}]}`)
+	})
+	mux.HandleFunc("/workspaces/2/projects", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":[]}`)
+	})
+	mux.HandleFunc("/projects/3/tasks", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":[{"completed":false,"name":"Task 1"}]}`)
+	})
+	s := newTestSource(&configuration.Configuration{AllAssignees: true, AllWorkspaces: true})
+	planned, err := s.Planned(context.Background())
+	expectedPlanned := []source.Item{
+		{Source: Name, Workspace: "Workspace 1", Name: "Task 1"},
+	}
+	assert.Equal(expectedPlanned, planned)
+	const expectedError = "workspace Workspace 2: no projects in workspace match the project filters"
+	assert.EqualError(err, expectedError)
+	_, err = s.Completed(context.Background(), midnight.AddDate(0, 0, -1), midnight)
+	assert.Error(err)
+}
+
+func TestSourceUserName(t *testing.T) {
+	setup()
+	defer teardown()
+	assert := assert.New(t)
+	userRequests := 0
+	mux.HandleFunc("/users/me", func(w http.ResponseWriter, r *http.Request) {
+		userRequests++
+		fmt.Fprint(w, `{"data":{"gid":"10","name":"User 1"}}`)
+	})
+	s := newTestSource(&configuration.Configuration{})
+	name, err := s.UserName(context.Background())
+	assert.Nil(err)
+	assert.Equal("User 1", name)
+	_, err = s.UserName(context.Background())
+	assert.Nil(err)
+	assert.Equal(1, userRequests)
 }
 
-func TestPrintCompletedTasksNoTasks(t *testing.T) {
-	oldStdout := os.Stdout
-	r, w, _ := os.Pipe() //nolint:errcheck
-	os.Stdout = w
+func TestSourceCurrentUserFailure(t *testing.T) {
+	setup()
+	defer teardown()
+	mux.HandleFunc("/users/me", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+	s := newTestSource(&configuration.Configuration{})
+	_, err := s.Planned(context.Background())
+	assert.Contains(t, err.Error(), "token rejected")
+}
+
+func TestCompletedItemsNoTasks(t *testing.T) {
 	var tasks []task
 	now := time.Now().Local()
 	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
-	var wg sync.WaitGroup
-	conf := &configuration.Configuration{
-		TodayMidnight: midnight,
-		EarliestDate:  midnight.AddDate(0, 0, -1).Format(time.RFC3339),
-		WG:            &wg,
-	}
-	printCompletedTasks(tasks, conf)
-	outputChan := make(chan string)
-	// copy the output in a separate goroutine so printing can't block indefinitely
-	go func() {
-		var buf bytes.Buffer
-		io.Copy(&buf, r) //nolint:errcheck
-		outputChan <- buf.String()
-	}()
-	w.Close()
-	os.Stdout = oldStdout
-	actualOutput := <-outputChan
-	const expectedOutput = "\nYesterday's Activity:\n"
-	assert.Equal(t, expectedOutput, actualOutput)
-}
-
-func TestPrintCompletedTasksAllAfterTodayMidnight(t *testing.T) {
-	oldStdout := os.Stdout
-	r, w, _ := os.Pipe() //nolint:errcheck
-	os.Stdout = w
+	actualItems := completedItems(tasks, midnight.AddDate(0, 0, -1), midnight)
+	var expectedItems []source.Item
+	assert.Equal(t, expectedItems, actualItems)
+}
+
+func TestCompletedItemsAllAfterTodayMidnight(t *testing.T) {
 	now := time.Now().Local()
 	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
 	completedAt := time.Date(now.Year(), now.Month(), now.Day(), 12, 0, 0, 0, time.Local)
@@ -508,31 +1664,12 @@ func TestPrintCompletedTasksAllAfterTodayMidnight(t *testing.T) {
 		{Completed: true, CompletedAt: completedAt, Name: "Task 1"},
 		{Completed: true, CompletedAt: completedAt, Name: "Task 2"},
 	}
-	var wg sync.WaitGroup
-	conf := &configuration.Configuration{
-		TodayMidnight: midnight,
-		EarliestDate:  midnight.AddDate(0, 0, -1).Format(time.RFC3339),
-		WG:            &wg,
-	}
-	printCompletedTasks(tasks, conf)
-	outputChan := make(chan string)
-	// copy the output in a separate goroutine so printing can't block indefinitely
-	go func() {
-		var buf bytes.Buffer
-		io.Copy(&buf, r) //nolint:errcheck
-		outputChan <- buf.String()
-	}()
-	w.Close()
-	os.Stdout = oldStdout
-	actualOutput := <-outputChan
-	const expectedOutput = "\nYesterday's Activity:\n"
-	assert.Equal(t, expectedOutput, actualOutput)
-}
-
-func TestPrintCompletedTasksSomeAfterTodayMidnightSomeBeforeTodayMidnight(t *testing.T) {
-	oldStdout := os.Stdout
-	r, w, _ := os.Pipe() //nolint:errcheck
-	os.Stdout = w
+	actualItems := completedItems(tasks, midnight.AddDate(0, 0, -1), midnight)
+	var expectedItems []source.Item
+	assert.Equal(t, expectedItems, actualItems)
+}
+
+func TestCompletedItemsSomeAfterTodayMidnightSomeBeforeTodayMidnight(t *testing.T) {
 	now := time.Now().Local()
 	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
 	completedAt1 := time.Date(now.Year(), now.Month(), now.Day()-1, 12, 0, 0, 0, time.Local)
@@ -541,162 +1678,133 @@ func TestPrintCompletedTasksSomeAfterTodayMidnightSomeBeforeTodayMidnight(t *tes
 		{Completed: true, CompletedAt: completedAt1, Name: "Task 1"},
 		{Completed: true, CompletedAt: completedAt2, Name: "Task 2"},
 	}
-	var wg sync.WaitGroup
-	conf := &configuration.Configuration{
-		TodayMidnight: midnight,
-		EarliestDate:  midnight.AddDate(0, 0, -1).Format(time.RFC3339),
-		WG:            &wg,
-	}
-	printCompletedTasks(tasks, conf)
-	outputChan := make(chan string)
-	// copy the output in a separate goroutine so printing can't block indefinitely
-	go func() {
-		var buf bytes.Buffer
-		io.Copy(&buf, r) //nolint:errcheck
-		outputChan <- buf.String()
-	}()
-	w.Close()
-	os.Stdout = oldStdout
-	actualOutput := <-outputChan
-	const expectedOutput = "\nYesterday's Activity:\n- Task 1\n"
-	assert.Equal(t, expectedOutput, actualOutput)
-}
-
-func TestPrintCompletedTasksAllBeforeTodayMidnight(t *testing.T) { //nolint:dupl
-	oldStdout := os.Stdout
-	r, w, _ := os.Pipe() //nolint:errcheck
-	os.Stdout = w
+	actualItems := completedItems(tasks, midnight.AddDate(0, 0, -1), midnight)
+	expectedItems := []source.Item{
+		{Source: Name, Name: "Task 1", CompletedAt: completedAt1},
+	}
+	assert.Equal(t, expectedItems, actualItems)
+}
+
+func TestCompletedItemsBeforeEarliestDate(t *testing.T) {
 	now := time.Now().Local()
 	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
-	completedAt1 := time.Date(now.Year(), now.Month(), now.Day()-1, 12, 0, 0, 0, time.Local)
+	completedAt1 := time.Date(now.Year(), now.Month(), now.Day()-2, 12, 0, 0, 0, time.Local)
 	completedAt2 := time.Date(now.Year(), now.Month(), now.Day()-1, 12, 0, 0, 0, time.Local)
 	tasks := []task{
 		{Completed: true, CompletedAt: completedAt1, Name: "Task 1"},
 		{Completed: true, CompletedAt: completedAt2, Name: "Task 2"},
 	}
-	var wg sync.WaitGroup
-	conf := &configuration.Configuration{
-		TodayMidnight: midnight,
-		EarliestDate:  midnight.AddDate(0, 0, -1).Format(time.RFC3339),
-		WG:            &wg,
-	}
-	printCompletedTasks(tasks, conf)
-	outputChan := make(chan string)
-	// copy the output in a separate goroutine so printing can't block indefinitely
-	go func() {
-		var buf bytes.Buffer
-		io.Copy(&buf, r) //nolint:errcheck
-		outputChan <- buf.String()
-	}()
-	w.Close()
-	os.Stdout = oldStdout
-	actualOutput := <-outputChan
-	const expectedOutput = "\nYesterday's Activity:\n- Task 1\n- Task 2\n"
-	assert.Equal(t, expectedOutput, actualOutput)
-}
-
-func TestPrintCompletedTasksNotSortedDateOrder(t *testing.T) { //nolint:dupl
-	oldStdout := os.Stdout
-	r, w, _ := os.Pipe() //nolint:errcheck
-	os.Stdout = w
+	actualItems := completedItems(tasks, midnight.AddDate(0, 0, -1), midnight)
+	expectedItems := []source.Item{
+		{Source: Name, Name: "Task 2", CompletedAt: completedAt2},
+	}
+	assert.Equal(t, expectedItems, actualItems)
+}
+
+func TestCompletedItemsAllBeforeTodayMidnight(t *testing.T) {
+	now := time.Now().Local()
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	completedAt1 := time.Date(now.Year(), now.Month(), now.Day()-1, 12, 0, 0, 0, time.Local)
+	completedAt2 := time.Date(now.Year(), now.Month(), now.Day()-1, 13, 0, 0, 0, time.Local)
+	tasks := []task{
+		{Completed: true, CompletedAt: completedAt1, Name: "Task 1", Workspace: "Workspace 1"},
+		{Completed: true, CompletedAt: completedAt2, Name: "Task 2", Workspace: "Workspace 2"},
+		{Completed: false, Name: "Task 3"},
+	}
+	actualItems := completedItems(tasks, midnight.AddDate(0, 0, -1), midnight)
+	expectedItems := []source.Item{
+		{Source: Name, Workspace: "Workspace 1", Name: "Task 1", CompletedAt: completedAt1},
+		{Source: Name, Workspace: "Workspace 2", Name: "Task 2", CompletedAt: completedAt2},
+	}
+	assert.Equal(t, expectedItems, actualItems)
+}
+
+func TestCompletedItemsSubtasks(t *testing.T) {
 	now := time.Now().Local()
 	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
-	completedAt1 := time.Date(now.Year(), now.Month(), now.Day()-1, 13, 0, 0, 0, time.Local)
-	completedAt2 := time.Date(now.Year(), now.Month(), now.Day()-1, 12, 0, 0, 0, time.Local)
+	completedAt1 := time.Date(now.Year(), now.Month(), now.Day()-1, 12, 0, 0, 0, time.Local)
+	completedAt2 := time.Date(now.Year(), now.Month(), now.Day()-2, 12, 0, 0, 0, time.Local)
 	tasks := []task{
-		{Completed: true, CompletedAt: completedAt1, Name: "Task 1"},
-		{Completed: true, CompletedAt: completedAt2, Name: "Task 2"},
+		{Name: "Task 1", Subtasks: []task{
+			{Completed: true, CompletedAt: completedAt1, Name: "Subtask 1"},
+			{Completed: true, CompletedAt: completedAt2, Name: "Subtask 2"},
+			{Completed: false, Name: "Subtask 3"},
+		}},
+		{Name: "Task 2", Subtasks: []task{
+			{Completed: true, CompletedAt: completedAt2, Name: "Subtask 4"},
+		}},
+		{Completed: true, CompletedAt: completedAt1, Name: "Task 3", Subtasks: []task{
+			{Completed: true, CompletedAt: completedAt2, Name: "Subtask 5"},
+		}},
+	}
+	actualItems := completedItems(tasks, midnight.AddDate(0, 0, -1), midnight)
+	expectedItems := []source.Item{
+		{
+			Source:       Name,
+			Name:         "Task 1",
+			Subtasks:     []source.Item{{Source: Name, Name: "Subtask 1", CompletedAt: completedAt1}},
+			SubtaskCount: 3,
+			SubtasksDone: 2,
+		},
+		{Source: Name, Name: "Task 3", CompletedAt: completedAt1, SubtaskCount: 1, SubtasksDone: 1},
+	}
+	assert.Equal(t, expectedItems, actualItems)
+}
+
+func TestPlannedItemsSubtasks(t *testing.T) {
+	tasks := []task{
+		{Name: "Task 1", Subtasks: []task{
+			{Completed: true, Name: "Subtask 1"},
+			{Completed: false, Name: "Subtask 2"},
+		}},
+	}
+	actualItems := plannedItems(tasks, &plannedFilter{allTasks: true, location: time.Local}, &blockerFilter{})
+	expectedItems := []source.Item{
+		{
+			Source:       Name,
+			Name:         "Task 1",
+			Subtasks:     []source.Item{{Source: Name, Name: "Subtask 2"}},
+			SubtaskCount: 2,
+			SubtasksDone: 1,
+		},
 	}
-	var wg sync.WaitGroup
-	conf := &configuration.Configuration{
-		TodayMidnight: midnight,
-		EarliestDate:  midnight.AddDate(0, 0, -1).Format(time.RFC3339),
-		WG:            &wg,
-	}
-	printCompletedTasks(tasks, conf)
-	outputChan := make(chan string)
-	// copy the output in a separate goroutine so printing can't block indefinitely
-	go func() {
-		var buf bytes.Buffer
-		io.Copy(&buf, r) //nolint:errcheck
-		outputChan <- buf.String()
-	}()
-	w.Close()
-	os.Stdout = oldStdout
-	actualOutput := <-outputChan
-	const expectedOutput = "\nYesterday's Activity:\n- Task 2\n- Task 1\n"
-	assert.Equal(t, expectedOutput, actualOutput)
-}
-
-func TestPrintIncompleteTasksNoIncomplete(t *testing.T) {
-	oldStdout := os.Stdout
-	r, w, _ := os.Pipe() //nolint:errcheck
-	os.Stdout = w
+	assert.Equal(t, expectedItems, actualItems)
+}
+
+func TestPlannedItemsNoIncomplete(t *testing.T) {
 	now := time.Now().Local()
 	completedAt := time.Date(now.Year(), now.Month(), now.Day()-1, 13, 0, 0, 0, time.Local)
 	tasks := []task{
 		{Completed: true, CompletedAt: completedAt, Name: "Task 1"},
 	}
-	printIncompleteTasks(tasks)
-	outputChan := make(chan string)
-	// copy the output in a separate goroutine so printing can't block indefinitely
-	go func() {
-		var buf bytes.Buffer
-		io.Copy(&buf, r) //nolint:errcheck
-		outputChan <- buf.String()
-	}()
-	w.Close()
-	os.Stdout = oldStdout
-	actualOutput := <-outputChan
-	const expectedOutput = "\nToday's Planned Activity:\n\n"
-	assert.Equal(t, expectedOutput, actualOutput)
-}
-
-func TestPrintIncompleteTasksSomeIncompleteSomeComplete(t *testing.T) {
-	oldStdout := os.Stdout
-	r, w, _ := os.Pipe() //nolint:errcheck
-	os.Stdout = w
+	actualItems := plannedItems(tasks, &plannedFilter{allTasks: true, location: time.Local}, &blockerFilter{})
+	var expectedItems []source.Item
+	assert.Equal(t, expectedItems, actualItems)
+}
+
+func TestPlannedItemsSomeIncompleteSomeComplete(t *testing.T) {
 	now := time.Now().Local()
 	completedAt := time.Date(now.Year(), now.Month(), now.Day()-1, 13, 0, 0, 0, time.Local)
 	tasks := []task{
 		{Completed: false, CompletedAt: completedAt, Name: "Task 1"},
 		{Completed: true, CompletedAt: completedAt, Name: "Task 2"},
 	}
-	printIncompleteTasks(tasks)
-	outputChan := make(chan string)
-	// copy the output in a separate goroutine so printing can't block indefinitely
-	go func() {
-		var buf bytes.Buffer
-		io.Copy(&buf, r) //nolint:errcheck
-		outputChan <- buf.String()
-	}()
-	w.Close()
-	os.Stdout = oldStdout
-	actualOutput := <-outputChan
-	const expectedOutput = "\nToday's Planned Activity:\n- Task 1\n\n"
-	assert.Equal(t, expectedOutput, actualOutput)
-}
-
-func TestPrintIncompleteTasksAllIncomplete(t *testing.T) {
-	oldStdout := os.Stdout
-	r, w, _ := os.Pipe() //nolint:errcheck
-	os.Stdout = w
-	now := time.Now().Local()
-	completedAt := time.Date(now.Year(), now.Month(), now.Day()-1, 13, 0, 0, 0, time.Local)
+	actualItems := plannedItems(tasks, &plannedFilter{allTasks: true, location: time.Local}, &blockerFilter{})
+	expectedItems := []source.Item{
+		{Source: Name, Name: "Task 1"},
+	}
+	assert.Equal(t, expectedItems, actualItems)
+}
+
+func TestPlannedItemsAllIncomplete(t *testing.T) {
 	tasks := []task{
-		{Completed: false, CompletedAt: completedAt, Name: "Task 1"},
+		{Completed: false, Name: "Task 1"},
+		{Completed: false, Name: "Task 2"},
+	}
+	actualItems := plannedItems(tasks, &plannedFilter{allTasks: true, location: time.Local}, &blockerFilter{})
+	expectedItems := []source.Item{
+		{Source: Name, Name: "Task 1"},
+		{Source: Name, Name: "Task 2"},
 	}
-	printIncompleteTasks(tasks)
-	outputChan := make(chan string)
-	// copy the output in a separate goroutine so printing can't block indefinitely
-	go func() {
-		var buf bytes.Buffer
-		io.Copy(&buf, r) //nolint:errcheck
-		outputChan <- buf.String()
-	}()
-	w.Close()
-	os.Stdout = oldStdout
-	actualOutput := <-outputChan
-	const expectedOutput = "\nToday's Planned Activity:\n- Task 1\n\n"
-	assert.Equal(t, expectedOutput, actualOutput)
+	assert.Equal(t, expectedItems, actualItems)
 }
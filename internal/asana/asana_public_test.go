@@ -1,7 +1,27 @@
 package asana_test
 
-import "testing"
+import (
+	"testing"
 
-func TestReport(t *testing.T) {
+	"github.com/stretchr/testify/assert"
 
+	"github.com/jeremy-miller/standup-reporter/internal/asana"
+	"github.com/jeremy-miller/standup-reporter/internal/configuration"
+)
+
+func TestNew(t *testing.T) {
+	assert := assert.New(t)
+	src, err := asana.New(&configuration.Configuration{AsanaToken: "123abc"})
+	assert.Nil(err)
+	assert.Equal(asana.Name, src.Name())
+}
+
+func TestNewInvalidProjectFilter(t *testing.T) {
+	_, err := asana.New(&configuration.Configuration{AsanaToken: "123abc", Projects: []string{"/(/"}})
+	assert.Error(t, err)
+}
+
+func TestNewMissingToken(t *testing.T) {
+	_, err := asana.New(&configuration.Configuration{})
+	assert.EqualError(t, err, "missing Asana personal access token")
 }
@@ -0,0 +1,113 @@
+package asana
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+/*
+APIError is returned when Asana responds with a non-2xx status code.  It contains the status code along with the error
+messages and help text from the Asana response body.
+*/
+type APIError struct {
+	StatusCode int           // HTTP status code of the response.
+	Messages   []string      // Error messages from the response body.
+	Help       string        // Help text from the response body.
+	RetryAfter time.Duration // Delay requested by the Retry-After header, if any.
+}
+
+type errorResponse struct {
+	Errors []struct {
+		Message string `json:"message"`
+		Help    string `json:"help"`
+	} `json:"errors"`
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("Asana responded with %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	if len(e.Messages) > 0 {
+		msg = fmt.Sprintf("%s: %s", msg, strings.Join(e.Messages, "; "))
+	}
+	if e.Help != "" {
+		msg = fmt.Sprintf("%s (%s)", msg, e.Help)
+	}
+	return msg
+}
+
+func newAPIError(statusCode int, body errorResponse) *APIError {
+	apiErr := &APIError{StatusCode: statusCode}
+	for _, e := range body.Errors {
+		if e.Message != "" {
+			apiErr.Messages = append(apiErr.Messages, e.Message)
+		}
+		if apiErr.Help == "" {
+			apiErr.Help = e.Help
+		}
+	}
+	return apiErr
+}
+
+/*
+projectErrors aggregates the errors encountered while retrieving the tasks of individual projects.
+*/
+type projectErrors []error
+
+func (e projectErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+	return fmt.Sprintf("error retrieving tasks for %d project(s):\n%s", len(e), strings.Join(msgs, "\n"))
+}
+
+/*
+errorList aggregates independent errors, e.g. those of several workspaces.
+*/
+type errorList []error
+
+func (e errorList) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "\n")
+}
+
+/*
+appendError adds an error to a possibly nil error, aggregating both if needed.
+*/
+func appendError(err, other error) error {
+	if err == nil {
+		return other
+	}
+	return errorList{err, other}
+}
+
+/*
+explain prefixes an APIError with an actionable description of what went wrong while retrieving the given resource.
+Other errors are returned unchanged.
+*/
+func explain(err error, resource string) error {
+	var apiErr *APIError
+	if !xerrors.As(err, &apiErr) {
+		return err
+	}
+	switch {
+	case apiErr.StatusCode == http.StatusUnauthorized:
+		return xerrors.Errorf("token rejected, check your Asana personal access token: %w", err)
+	case apiErr.StatusCode == http.StatusForbidden:
+		return xerrors.Errorf("access to %s denied: %w", resource, err)
+	case apiErr.StatusCode == http.StatusNotFound:
+		return xerrors.Errorf("%s not found, it may have been deleted: %w", resource, err)
+	case apiErr.StatusCode == http.StatusTooManyRequests:
+		return xerrors.Errorf("rate limited by Asana, try again later: %w", err)
+	case apiErr.StatusCode >= http.StatusInternalServerError:
+		return xerrors.Errorf("Asana is unavailable, try again later: %w", err)
+	default:
+		return err
+	}
+}
@@ -0,0 +1,290 @@
+package asana
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"github.com/jeremy-miller/standup-reporter/internal/configuration"
+)
+
+const (
+	dateLayout            = "2006-01-02"
+	defaultPlannedSection = "In Progress" // Section of planned tasks when no section patterns are given.
+)
+
+type project struct {
+	Gid      string `json:"gid"`
+	Name     string `json:"name"`
+	Archived bool   `json:"archived"`
+	Team     *entry `json:"team"`
+}
+
+/*
+projectFilter determines which projects of a workspace are used in the report, based on project and team names, and
+which tasks of those projects are used, based on section names.
+*/
+type projectFilter struct {
+	includeArchived bool
+	projects        []*regexp.Regexp // If any, a project's name must match one of these.
+	excludeProjects []*regexp.Regexp // A project's name must not match any of these.
+	teams           []*regexp.Regexp // If any, a project's team name must match one of these.
+	excludeTeams    []*regexp.Regexp // A project's team name must not match any of these.
+	sections        []*regexp.Regexp // If any, a task's section name must match one of these.
+	excludeSections []*regexp.Regexp // A task's section name must not match any of these.
+}
+
+func newProjectFilter(config *configuration.Configuration) (*projectFilter, error) {
+	filter := &projectFilter{includeArchived: config.IncludeArchived}
+	var err error
+	if filter.projects, err = compilePatterns(config.Projects); err != nil {
+		return nil, xerrors.Errorf("error parsing project filter: %w", err)
+	}
+	if filter.excludeProjects, err = compilePatterns(config.ExcludeProjects); err != nil {
+		return nil, xerrors.Errorf("error parsing excluded project filter: %w", err)
+	}
+	if filter.teams, err = compilePatterns(config.Teams); err != nil {
+		return nil, xerrors.Errorf("error parsing team filter: %w", err)
+	}
+	if filter.excludeTeams, err = compilePatterns(config.ExcludeTeams); err != nil {
+		return nil, xerrors.Errorf("error parsing excluded team filter: %w", err)
+	}
+	if filter.sections, err = compilePatterns(config.Sections); err != nil {
+		return nil, xerrors.Errorf("error parsing section filter: %w", err)
+	}
+	if filter.excludeSections, err = compilePatterns(config.ExcludeSections); err != nil {
+		return nil, xerrors.Errorf("error parsing excluded section filter: %w", err)
+	}
+	return filter, nil
+}
+
+func (f *projectFilter) apply(projects []project) []project {
+	var filteredProjects []project
+	for i, project := range projects {
+		if f.matches(project) {
+			filteredProjects = append(filteredProjects, projects[i])
+		}
+	}
+	return filteredProjects
+}
+
+func (f *projectFilter) matches(p project) bool {
+	if p.Archived && !f.includeArchived {
+		return false
+	}
+	if !matchesFilter(p.Name, f.projects, f.excludeProjects) {
+		return false
+	}
+	if len(f.teams) > 0 || len(f.excludeTeams) > 0 {
+		if p.Team == nil {
+			return len(f.teams) == 0
+		}
+		return matchesFilter(p.Team.Name, f.teams, f.excludeTeams)
+	}
+	return true
+}
+
+/*
+applyTasks returns the tasks whose sections match the section filters.
+*/
+func (f *projectFilter) applyTasks(tasks []task) []task {
+	if len(f.sections) == 0 && len(f.excludeSections) == 0 {
+		return tasks
+	}
+	var filteredTasks []task
+	for i, task := range tasks {
+		if matchesFilter(task.Section, f.sections, f.excludeSections) {
+			filteredTasks = append(filteredTasks, tasks[i])
+		}
+	}
+	return filteredTasks
+}
+
+func matchesFilter(name string, include, exclude []*regexp.Regexp) bool {
+	if len(include) > 0 && !matchesAny(name, include) {
+		return false
+	}
+	return !matchesAny(name, exclude)
+}
+
+func matchesAny(name string, patterns []*regexp.Regexp) bool {
+	for _, pattern := range patterns {
+		if pattern.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+func compilePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	var compiled []*regexp.Regexp
+	for _, pattern := range patterns {
+		re, err := compilePattern(pattern)
+		if err != nil {
+			return nil, err
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+/*
+compilePattern compiles a name filter.  Patterns wrapped in slashes (e.g. "/^Eng.*$/") are regular expressions, all
+other patterns are case insensitive globs matching the whole name, where "*" matches any characters and "?" matches a
+single character.
+*/
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	if len(pattern) >= 2 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+		re, err := regexp.Compile(pattern[1 : len(pattern)-1])
+		if err != nil {
+			return nil, xerrors.Errorf("invalid regular expression \"%s\": %w", pattern, err)
+		}
+		return re, nil
+	}
+	var expr strings.Builder
+	expr.WriteString("(?i)^")
+	for _, r := range pattern {
+		switch r {
+		case '*':
+			expr.WriteString(".*")
+		case '?':
+			expr.WriteString(".")
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	expr.WriteString("$")
+	return regexp.MustCompile(expr.String()), nil
+}
+
+/*
+plannedFilter determines which incomplete tasks are planned, based on the planned-task policies.  Without policies, all
+incomplete tasks are planned; otherwise a task is planned if it matches any of the policies.
+*/
+type plannedFilter struct {
+	due      bool             // Plan tasks due on or before dueBy.
+	dueBy    string           // Last due date (YYYY-MM-DD) of planned tasks.
+	started  bool             // Plan tasks started on or before today.
+	today    string           // Today's date (YYYY-MM-DD).
+	sections []*regexp.Regexp // If any, plan tasks in sections whose names match one of these.
+	location *time.Location   // Timezone in which due times are converted to dates.
+	allTasks bool             // Plan all incomplete tasks, since no policies are set.
+}
+
+func newPlannedFilter(config *configuration.Configuration) (*plannedFilter, error) {
+	today := config.TodayMidnight
+	if today.IsZero() {
+		now := time.Now()
+		today = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	}
+	filter := &plannedFilter{
+		allTasks: len(config.Planned) == 0,
+		dueBy:    today.AddDate(0, 0, config.DueWithin).Format(dateLayout),
+		today:    today.Format(dateLayout),
+		location: today.Location(),
+	}
+	for _, policy := range config.Planned {
+		switch policy {
+		case configuration.PlannedDue:
+			filter.due = true
+		case configuration.PlannedStarted:
+			filter.started = true
+		case configuration.PlannedSection:
+			patterns := config.PlannedSections
+			if len(patterns) == 0 {
+				patterns = []string{defaultPlannedSection}
+			}
+			var err error
+			if filter.sections, err = compilePatterns(patterns); err != nil {
+				return nil, xerrors.Errorf("error parsing planned section filter: %w", err)
+			}
+		default:
+			return nil, xerrors.Errorf("unknown planned-task policy %q", policy)
+		}
+	}
+	return filter, nil
+}
+
+func (f *plannedFilter) matches(t task) bool {
+	if f.allTasks {
+		return true
+	}
+	if f.due {
+		if due := t.dueDate(f.location); due != "" && due <= f.dueBy {
+			return true
+		}
+	}
+	if f.started && t.StartOn != "" && t.StartOn <= f.today {
+		return true
+	}
+	return len(f.sections) > 0 && matchesAny(t.Section, f.sections)
+}
+
+/*
+blockerFilter determines which incomplete tasks are blocked, which are reported as blockers regardless of the
+planned-task policies.  A task is blocked if it matches any of the rules.
+*/
+type blockerFilter struct {
+	sections     []*regexp.Regexp // Tasks in sections whose names match any of these are blocked.
+	tags         []*regexp.Regexp // Tasks with tags whose names match any of these are blocked.
+	fields       []fieldPattern   // Tasks with enum custom field values matching any of these are blocked.
+	dependencies bool             // Tasks with incomplete dependencies are blocked.
+}
+
+/*
+fieldPattern matches the value of an enum custom field.
+*/
+type fieldPattern struct {
+	name  *regexp.Regexp
+	value *regexp.Regexp
+}
+
+func newBlockerFilter(config *configuration.Configuration) (*blockerFilter, error) {
+	filter := &blockerFilter{dependencies: config.BlockedByDeps}
+	var err error
+	if filter.sections, err = compilePatterns(config.BlockedSections); err != nil {
+		return nil, xerrors.Errorf("error parsing blocked section filter: %w", err)
+	}
+	if filter.tags, err = compilePatterns(config.BlockedTags); err != nil {
+		return nil, xerrors.Errorf("error parsing blocked tag filter: %w", err)
+	}
+	for _, field := range config.BlockedFields {
+		parts := strings.SplitN(field, "=", 2)
+		if len(parts) != 2 {
+			return nil, xerrors.Errorf("error parsing blocked field filter: %q must be NAME=VALUE", field)
+		}
+		var pattern fieldPattern
+		if pattern.name, err = compilePattern(parts[0]); err != nil {
+			return nil, xerrors.Errorf("error parsing blocked field filter: %w", err)
+		}
+		if pattern.value, err = compilePattern(parts[1]); err != nil {
+			return nil, xerrors.Errorf("error parsing blocked field filter: %w", err)
+		}
+		filter.fields = append(filter.fields, pattern)
+	}
+	return filter, nil
+}
+
+func (f *blockerFilter) matches(t task) bool {
+	if matchesAny(t.Section, f.sections) {
+		return true
+	}
+	for _, tag := range t.Tags {
+		if matchesAny(tag.Name, f.tags) {
+			return true
+		}
+	}
+	for _, field := range t.CustomFields {
+		if field.EnumValue == nil {
+			continue
+		}
+		for _, pattern := range f.fields {
+			if pattern.name.MatchString(field.Name) && pattern.value.MatchString(field.EnumValue.Name) {
+				return true
+			}
+		}
+	}
+	return f.dependencies && len(t.openDependencies()) > 0
+}
@@ -0,0 +1,116 @@
+package asana
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"github.com/jeremy-miller/standup-reporter/internal/configuration"
+)
+
+type retryPolicy struct {
+	maxAttempts int           // Total number of attempts per request, including the first.
+	baseDelay   time.Duration // Delay before the first retry, doubled for each subsequent retry.
+	maxWait     time.Duration // Maximum delay between two attempts.
+}
+
+func defaultRetryPolicy() retryPolicy {
+	return retryPolicy{
+		maxAttempts: 4,
+		baseDelay:   time.Millisecond * 500,
+		maxWait:     time.Second * 30,
+	}
+}
+
+/*
+configure overrides the retry policy with any retry settings given in the configuration.
+*/
+func (p *retryPolicy) configure(config *configuration.Configuration) {
+	if config.MaxAttempts > 0 {
+		p.maxAttempts = config.MaxAttempts
+	}
+	if config.MaxRetryWait > 0 {
+		p.maxWait = config.MaxRetryWait
+	}
+}
+
+/*
+delay returns how long to wait before retrying after the given attempt failed.  A delay requested by Asana is used
+as-is, otherwise the delay grows exponentially from baseDelay, capped at maxWait, with random jitter applied.
+*/
+func (p retryPolicy) delay(attempt int, retryAfter time.Duration) time.Duration {
+	if retryAfter > 0 {
+		return retryAfter
+	}
+	backoff := p.baseDelay << uint(attempt-1)
+	if backoff <= 0 || backoff > p.maxWait { // guard against overflow as well as the cap
+		backoff = p.maxWait
+	}
+	half := backoff / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1)) //nolint:gosec
+}
+
+/*
+wait blocks until the next attempt may be made.  An error is returned if the context is done first or if Asana requested
+a delay longer than maxWait.
+*/
+func (p retryPolicy) wait(ctx context.Context, attempt int, err error) error {
+	var retryAfter time.Duration
+	var apiErr *APIError
+	if xerrors.As(err, &apiErr) {
+		retryAfter = apiErr.RetryAfter
+	}
+	delay := p.delay(attempt, retryAfter)
+	if delay > p.maxWait {
+		return xerrors.Errorf("requested retry delay of %s exceeds maximum of %s", delay, p.maxWait)
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+/*
+retryable reports whether a failed request should be attempted again: rate-limited requests, server errors and network
+errors are retried, unless the context is done.
+*/
+func retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var apiErr *APIError
+	if xerrors.As(err, &apiErr) {
+		return apiErr.StatusCode == http.StatusTooManyRequests || apiErr.StatusCode >= http.StatusInternalServerError
+	}
+	var urlErr *url.Error
+	return xerrors.As(err, &urlErr)
+}
+
+/*
+parseRetryAfter parses the value of a Retry-After header, which is either a number of seconds or an HTTP date.  Zero is
+returned if the header is missing or invalid.
+*/
+func parseRetryAfter(header string, now time.Time) time.Duration {
+	if header == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(header); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(header); err == nil && date.After(now) {
+		return date.Sub(now)
+	}
+	return 0
+}
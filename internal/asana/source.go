@@ -0,0 +1,341 @@
+package asana
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"github.com/jeremy-miller/standup-reporter/internal/configuration"
+	"github.com/jeremy-miller/standup-reporter/internal/source"
+)
+
+/*
+Name is the name of the Asana source.
+*/
+const Name = "asana"
+
+/*
+Source retrieves tasks from Asana.  Tasks are cached after they are first retrieved, so completed and planned items are
+provided without querying Asana twice.
+*/
+type Source struct {
+	client   *client
+	config   *configuration.Configuration
+	filter   *projectFilter
+	planned  *plannedFilter
+	blockers *blockerFilter
+
+	mu     sync.Mutex
+	user   *entry // Authenticated user, once retrieved.
+	loaded bool
+	since  time.Time // Tasks completed before this time aren't cached.
+	tasks  []task
+	err    error
+}
+
+/*
+New creates an Asana source from the configuration.
+*/
+func New(config *configuration.Configuration) (source.Source, error) {
+	if config.AsanaToken == "" {
+		return nil, xerrors.New("missing Asana personal access token")
+	}
+	filter, err := newProjectFilter(config)
+	if err != nil {
+		return nil, err
+	}
+	planned, err := newPlannedFilter(config)
+	if err != nil {
+		return nil, err
+	}
+	blockers, err := newBlockerFilter(config)
+	if err != nil {
+		return nil, err
+	}
+	client := getClient(config.AsanaToken)
+	client.retry.configure(config)
+	client.concurrency = config.Concurrency
+	client.withSubtasks = config.Subtasks != "" && config.Subtasks != configuration.SubtasksOff
+	return &Source{
+		client:   client,
+		config:   config,
+		filter:   filter,
+		planned:  planned,
+		blockers: blockers,
+	}, nil
+}
+
+/*
+Name returns the name of the Asana source.
+*/
+func (s *Source) Name() string {
+	return Name
+}
+
+/*
+Completed returns the tasks completed within [since, until).
+*/
+func (s *Source) Completed(ctx context.Context, since, until time.Time) ([]source.Item, error) {
+	tasks, err := s.load(ctx, since)
+	return completedItems(s.filter.applyTasks(tasks), since, until), err
+}
+
+/*
+Planned returns the incomplete tasks which match the planned-task policies, along with all blocked tasks.
+*/
+func (s *Source) Planned(ctx context.Context) ([]source.Item, error) {
+	tasks, err := s.load(ctx, time.Now()) // incomplete tasks are retrieved regardless of the completion window
+	return plannedItems(s.filter.applyTasks(tasks), s.planned, s.blockers), err
+}
+
+/*
+Activity returns the tasks the authenticated user commented on or attached files to within [since, until), along with
+the project status updates they posted.  No activity is retrieved unless it is reported.
+*/
+func (s *Source) Activity(ctx context.Context, since, until time.Time) ([]source.Item, error) {
+	if !s.config.Activity {
+		return nil, nil
+	}
+	s.mu.Lock()
+	user, err := s.currentUser(ctx)
+	s.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	workspaces, err := s.workspaces(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var items []source.Item
+	var errs errorList
+	for _, workspace := range workspaces {
+		workspaceItems, err := s.workspaceActivity(ctx, workspace, user.Gid, since, until)
+		for i := range workspaceItems {
+			if len(workspaces) > 1 {
+				workspaceItems[i].Workspace = workspace.Name
+			}
+		}
+		items = append(items, workspaceItems...)
+		if err != nil {
+			if ctx.Err() != nil {
+				return items, err
+			}
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return items, errs
+	}
+	return items, nil
+}
+
+/*
+workspaceActivity returns the activity of the user within [since, until) in the projects of a workspace.
+*/
+func (s *Source) workspaceActivity(ctx context.Context, workspace entry, userGID string, since, until time.Time) ([]source.Item, error) { //nolint:lll
+	projects, err := s.client.projects(ctx, workspace.Gid, s.filter)
+	if err != nil {
+		return nil, xerrors.Errorf("error retrieving projects: %w", explain(err, "workspace "+workspace.Name))
+	}
+	activities, err := s.client.allActivity(ctx, projects, userGID, since, until)
+	items := make([]source.Item, 0, len(activities))
+	for _, a := range activities {
+		items = append(items, a.item(since.Location()))
+	}
+	return items, err
+}
+
+/*
+UserName returns the name of the authenticated Asana user.
+*/
+func (s *Source) UserName(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user, err := s.currentUser(ctx)
+	if err != nil {
+		return "", err
+	}
+	return user.Name, nil
+}
+
+/*
+currentUser returns the authenticated user, retrieving it only once.  It must be called with mu held.
+*/
+func (s *Source) currentUser(ctx context.Context) (*entry, error) {
+	if s.user == nil {
+		user, err := s.client.currentUser(ctx)
+		if err != nil {
+			return nil, xerrors.Errorf("error retrieving current user: %w", explain(err, "current user"))
+		}
+		s.user = &user
+	}
+	return s.user, nil
+}
+
+/*
+load returns all incomplete tasks and the tasks completed since the given time, reusing cached tasks if they cover it.
+*/
+func (s *Source) load(ctx context.Context, since time.Time) ([]task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.loaded && !since.Before(s.since) {
+		return s.tasks, s.err
+	}
+	tasks, err := s.fetch(ctx, since)
+	if s.client.withSubtasks && ctx.Err() == nil {
+		var subtaskErr error
+		if tasks, subtaskErr = s.client.addSubtasks(ctx, tasks); subtaskErr != nil {
+			err = appendError(err, subtaskErr)
+		}
+	}
+	if ctx.Err() == nil {
+		s.loaded, s.since, s.tasks, s.err = true, since, tasks, err
+	}
+	return tasks, err
+}
+
+/*
+fetch retrieves the tasks of the selected workspaces.  Errors for individual workspaces are aggregated and returned
+alongside the tasks of the workspaces which succeeded.
+*/
+func (s *Source) fetch(ctx context.Context, since time.Time) ([]task, error) {
+	if err := s.setAssignee(ctx); err != nil {
+		return nil, err
+	}
+	workspaces, err := s.workspaces(ctx)
+	if err != nil {
+		return nil, err
+	}
+	multiple := len(workspaces) > 1
+	var tasks []task
+	var errs errorList
+	for _, workspace := range workspaces {
+		workspaceTasks, err := s.client.workspaceTasks(ctx, workspace, s.filter, since)
+		if multiple {
+			for i := range workspaceTasks {
+				workspaceTasks[i].Workspace = workspace.Name
+			}
+		}
+		tasks = append(tasks, workspaceTasks...)
+		if err != nil {
+			if !multiple || ctx.Err() != nil {
+				return tasks, err
+			}
+			errs = append(errs, xerrors.Errorf("workspace %s: %w", workspace.Name, err))
+		}
+	}
+	if len(errs) > 0 {
+		return tasks, errs
+	}
+	return tasks, nil
+}
+
+/*
+setAssignee limits the retrieved tasks to those assigned to the authenticated user, unless all assignees are reported.
+It must be called with mu held.
+*/
+func (s *Source) setAssignee(ctx context.Context) error {
+	if s.config.AllAssignees || s.client.assigneeGID != "" {
+		return nil
+	}
+	user, err := s.currentUser(ctx)
+	if err != nil {
+		return err
+	}
+	s.client.assigneeGID = user.Gid
+	return nil
+}
+
+/*
+workspaces retrieves the workspaces selected by the configuration.
+*/
+func (s *Source) workspaces(ctx context.Context) ([]entry, error) {
+	allWorkspaces, err := s.client.workspaces(ctx)
+	if err != nil {
+		return nil, xerrors.Errorf("error retrieving workspaces: %w", explain(err, "workspaces"))
+	}
+	return selectWorkspaces(allWorkspaces, s.config.Workspaces, s.config.AllWorkspaces)
+}
+
+/*
+completedItems returns the tasks completed within [since, until), along with the tasks which have subtasks completed
+within it.  Each task is reported with its subtasks completed within the window.  Tasks assigned to someone else are
+only reported for their subtasks.
+*/
+func completedItems(tasks []task, since, until time.Time) []source.Item {
+	var items []source.Item
+	for _, task := range tasks {
+		var subtasks []source.Item
+		for _, subtask := range task.Subtasks {
+			if subtask.completedWithin(since, until) {
+				subtasks = append(subtasks, subtask.item(since.Location()))
+			}
+		}
+		if (task.completedWithin(since, until) && !task.ForSubtasks) || len(subtasks) > 0 {
+			item := task.item(since.Location())
+			item.Subtasks = subtasks
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+/*
+plannedItems returns the incomplete tasks which are planned or blocked, with their incomplete subtasks.  Tasks assigned
+to someone else are only reported if they have incomplete subtasks.
+*/
+func plannedItems(tasks []task, planned *plannedFilter, blockers *blockerFilter) []source.Item {
+	var items []source.Item
+	for _, task := range tasks {
+		if task.Completed {
+			continue
+		}
+		blocked := blockers.matches(task)
+		if blocked || planned.matches(task) {
+			item := task.item(planned.location)
+			item.Blocked = blocked
+			for _, subtask := range task.Subtasks {
+				if !subtask.Completed {
+					item.Subtasks = append(item.Subtasks, subtask.item(planned.location))
+				}
+			}
+			if task.ForSubtasks && len(item.Subtasks) == 0 {
+				continue
+			}
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+/*
+item converts the task to an item, with its due time in the given timezone.
+*/
+func (t task) item(location *time.Location) source.Item {
+	item := source.Item{
+		Source:    Name,
+		Workspace: t.Workspace,
+		Project:   t.Project,
+		Section:   t.Section,
+		Name:      t.Name,
+		URL:       t.PermalinkURL,
+		CreatedAt: t.CreatedAt,
+		Due:       t.due(location),
+	}
+	for _, tag := range t.Tags {
+		item.Tags = append(item.Tags, tag.Name)
+	}
+	item.BlockedBy = t.openDependencies()
+	item.SubtaskCount = len(t.Subtasks)
+	for _, subtask := range t.Subtasks {
+		if subtask.Completed {
+			item.SubtasksDone++
+		}
+	}
+	if t.Completed && !t.ForSubtasks {
+		item.CompletedAt = t.CompletedAt
+	}
+	return item
+}
@@ -0,0 +1,75 @@
+package asana
+
+import (
+	"context"
+	"fmt"
+
+	"golang.org/x/xerrors"
+)
+
+/*
+subtasks retrieves the subtasks of a task.  When only the tasks of one assignee are retrieved, subtasks assigned to
+someone else are skipped, while unassigned subtasks are kept if the parent is assigned to the user, since they are
+usually done by the parent's assignee.
+*/
+func (c *client) subtasks(ctx context.Context, parent task) ([]task, error) {
+	path := fmt.Sprintf("tasks/%s/subtasks?opt_fields=name,completed,completed_at,created_at,assignee,permalink_url", parent.Gid) //nolint:lll
+	var tasks []task
+	if err := c.requestAll(ctx, path, &tasks); err != nil {
+		err = explain(err, "task "+parent.Gid)
+		return nil, xerrors.Errorf("error requesting subtasks of task %s: %v", parent.Gid, err)
+	}
+	subtasks := filterEmptyTasks(tasks)
+	if c.assigneeGID == "" {
+		return subtasks, nil
+	}
+	var filteredSubtasks []task
+	for i, subtask := range subtasks {
+		if subtask.assignedTo(c.assigneeGID) || (subtask.Assignee == nil && !parent.ForSubtasks) {
+			filteredSubtasks = append(filteredSubtasks, subtasks[i])
+		}
+	}
+	return filteredSubtasks, nil
+}
+
+/*
+addSubtasks retrieves the subtasks of the tasks which have any, for at most c.concurrency tasks concurrently, and drops
+the tasks of other assignees which have no subtasks assigned to the user.  Errors for individual tasks are aggregated
+and returned once the subtasks of the other tasks have been added.
+*/
+func (c *client) addSubtasks(ctx context.Context, tasks []task) ([]task, error) {
+	var parents []int
+	for i := range tasks {
+		if tasks[i].NumSubtasks > 0 && tasks[i].Gid != "" {
+			parents = append(parents, i)
+		}
+	}
+	errs := forEach(ctx, len(parents), c.concurrency, func(i int) error {
+		parent := &tasks[parents[i]] // each worker only modifies its own parent
+		subtasks, err := c.subtasks(ctx, *parent)
+		parent.Subtasks = subtasks
+		return err
+	})
+	tasks = dropParentsWithoutSubtasks(tasks)
+	if ctx.Err() != nil {
+		return tasks, xerrors.Errorf("error retrieving subtasks: %w", ctx.Err())
+	}
+	if len(errs) > 0 {
+		return tasks, errs
+	}
+	return tasks, nil
+}
+
+/*
+dropParentsWithoutSubtasks drops the tasks which are only reported for their subtasks assigned to the user, but have
+none.
+*/
+func dropParentsWithoutSubtasks(tasks []task) []task {
+	var filteredTasks []task
+	for i, task := range tasks {
+		if !task.ForSubtasks || len(task.Subtasks) > 0 {
+			filteredTasks = append(filteredTasks, tasks[i])
+		}
+	}
+	return filteredTasks
+}
@@ -0,0 +1,215 @@
+package configuration
+
+import (
+	"bufio"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+const (
+	dateLayout  = "2006-01-02"
+	maxLookback = 366 // Maximum number of days to go back looking for the previous working day.
+)
+
+/*
+Calendar defines the working days of the week and the holidays, which are used to find the previous working day.
+*/
+type Calendar struct {
+	workDays [7]bool         // Indexed by time.Weekday.
+	holidays map[string]bool // Dates (YYYY-MM-DD) which aren't working days.
+}
+
+/*
+NewCalendar creates a calendar from working day specifications and holidays.  Each working day specification is a
+comma separated list of days (e.g. "mon" or "monday") or ranges of days (e.g. "sun-thu").  Each holiday is either a
+date (YYYY-MM-DD) or the path of an iCalendar (.ics) file whose events are holidays.  Without working days, Monday to
+Friday are used.
+*/
+func NewCalendar(workDays []string, holidays []string) (*Calendar, error) {
+	c := &Calendar{holidays: make(map[string]bool)}
+	for _, spec := range workDays {
+		if err := c.addWorkDays(spec); err != nil {
+			return nil, err
+		}
+	}
+	if len(workDays) == 0 {
+		for weekday := time.Monday; weekday <= time.Friday; weekday++ {
+			c.workDays[weekday] = true
+		}
+	}
+	for _, holiday := range holidays {
+		if err := c.addHolidays(holiday); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+/*
+IsWorkDay reports whether the date of t is a working day.
+*/
+func (c *Calendar) IsWorkDay(t time.Time) bool {
+	return c.workDays[t.Weekday()] && !c.holidays[t.Format(dateLayout)]
+}
+
+func (c *Calendar) addWorkDays(spec string) error {
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		bounds := strings.SplitN(part, "-", 2)
+		first, ok := parseWeekdayPrefix(bounds[0])
+		if !ok {
+			return xerrors.Errorf("invalid working day %q", part)
+		}
+		last := first
+		if len(bounds) == 2 {
+			if last, ok = parseWeekdayPrefix(bounds[1]); !ok {
+				return xerrors.Errorf("invalid working day %q", part)
+			}
+		}
+		for weekday := first; ; weekday = (weekday + 1) % 7 { // ranges may wrap around the end of the week
+			c.workDays[weekday] = true
+			if weekday == last {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+func (c *Calendar) addHolidays(holiday string) error {
+	if strings.HasSuffix(strings.ToLower(holiday), ".ics") {
+		return c.addICSHolidays(holiday)
+	}
+	date, err := time.Parse(dateLayout, strings.TrimSpace(holiday))
+	if err != nil {
+		return xerrors.Errorf("invalid holiday %q, use YYYY-MM-DD or the path of an .ics file", holiday)
+	}
+	c.holidays[date.Format(dateLayout)] = true
+	return nil
+}
+
+/*
+addICSHolidays adds the dates of all events in an iCalendar file.  Events spanning several days add each of their
+days.  Recurring events are not expanded, so only their first occurrence is added.
+*/
+func (c *Calendar) addICSHolidays(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return xerrors.Errorf("error reading holiday calendar: %w", err)
+	}
+	defer f.Close()
+	lines, err := unfoldICSLines(bufio.NewScanner(f))
+	if err != nil {
+		return xerrors.Errorf("error reading holiday calendar: %w", err)
+	}
+	events, err := parseICSEvents(lines)
+	if err != nil {
+		return xerrors.Errorf("error parsing holiday calendar %s: %w", path, err)
+	}
+	for _, event := range events {
+		end := event.end
+		if !end.After(event.start) { // DTEND is exclusive and optional
+			end = event.start.AddDate(0, 0, 1)
+		}
+		for day := event.start; day.Before(end); day = day.AddDate(0, 0, 1) {
+			c.holidays[day.Format(dateLayout)] = true
+		}
+	}
+	return nil
+}
+
+/*
+icsEvent is the time span of an iCalendar event.
+*/
+type icsEvent struct {
+	start time.Time
+	end   time.Time // Exclusive, or the zero time if the event has no end.
+}
+
+/*
+parseICSEvents returns the events of the logical lines of an iCalendar file which have a start.
+*/
+func parseICSEvents(lines []string) ([]icsEvent, error) {
+	var events []icsEvent
+	var event icsEvent
+	for _, line := range lines {
+		var err error
+		name, value := splitICSLine(line)
+		switch {
+		case name == "BEGIN" && value == "VEVENT":
+			event = icsEvent{}
+		case name == "DTSTART":
+			event.start, err = parseICSDate(value)
+		case name == "DTEND":
+			event.end, err = parseICSDate(value)
+		case name == "END" && value == "VEVENT" && !event.start.IsZero():
+			events = append(events, event)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return events, nil
+}
+
+/*
+unfoldICSLines returns the logical lines of an iCalendar file, joining lines which were folded onto continuation lines
+(which start with a space or a tab).
+*/
+func unfoldICSLines(scanner *bufio.Scanner) ([]string, error) {
+	var lines []string
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+/*
+splitICSLine returns the property name (without parameters, e.g. ";VALUE=DATE") and the value of an iCalendar line.
+*/
+func splitICSLine(line string) (string, string) {
+	parts := strings.SplitN(line, ":", 2)
+	if len(parts) != 2 {
+		return "", ""
+	}
+	name := strings.SplitN(parts[0], ";", 2)[0]
+	return strings.ToUpper(name), strings.TrimSpace(parts[1])
+}
+
+/*
+parseICSDate parses the date of an iCalendar DATE (e.g. "20191225") or DATE-TIME (e.g. "20191225T000000Z") value.
+*/
+func parseICSDate(value string) (time.Time, error) {
+	if len(value) < len("20060102") {
+		return time.Time{}, xerrors.Errorf("invalid date %q", value)
+	}
+	date, err := time.Parse("20060102", value[:len("20060102")])
+	if err != nil {
+		return time.Time{}, xerrors.Errorf("invalid date %q", value)
+	}
+	return date, nil
+}
+
+func parseWeekdayPrefix(name string) (time.Weekday, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if len(name) < 3 {
+		return time.Sunday, false
+	}
+	for weekday := time.Sunday; weekday <= time.Saturday; weekday++ {
+		if strings.HasPrefix(strings.ToLower(weekday.String()), name) {
+			return weekday, true
+		}
+	}
+	return time.Sunday, false
+}
@@ -1,42 +1,136 @@
 /*
 Package configuration handles shared standup-reporter configuration.
+
+Unless specified, the default number of days to go back and get completed tasks for reaches back to the previous working
+day.  By default, working days are Monday to Friday, so it is 1, except if the script is run on a Monday, in which case
+it will go back 3 days (to account for the weekend).  The working days of the week and holidays can be configured.
+Alternatively, the window can be set to any dates, either absolute or relative to today (e.g. "last friday").  Days
+start at midnight in the local timezone, unless another timezone is configured.
 */
 package configuration
 
 import (
-	"sync"
 	"time"
+
+	"golang.org/x/xerrors"
 )
 
 /*
 Configuration defines the shared configuration parameters of the standup-reporter.
 */
 type Configuration struct {
-	TodayMidnight time.Time       // Today's date at midnight in the local timezone.
-	EarliestDate  string          // Midnight of the day for which Asana tasks will be retrieved.
-	WG            *sync.WaitGroup // WaitGroup used to coordinate goroutines.
+	Location        *time.Location // Timezone in which days start and times are displayed.
+	TodayMidnight   time.Time      // Today's date at midnight in Location.
+	EarliestDate    time.Time      // Start of the window for which completed tasks will be retrieved.
+	LatestDate      time.Time      // End (exclusive) of the window for which completed tasks will be retrieved.
+	TodayEnd        time.Time      // End (exclusive) of the tasks completed today which are reported, if any are.
+	MergeToday      bool           // Report tasks completed today with the other completed tasks, not separately.
+	Sources         []string       // Names of the enabled sources.
+	Format          string         // Output format of the report.
+	Template        string         // Path of a text/template used to render the report instead of the output format.
+	Sort            []string       // Keys the items of each section are sorted by, in order of precedence.
+	GroupBy         string         // Field the items of each section are grouped by, if any.
+	Subtasks        string         // How subtasks are reported ("off", "nest" or "rollup").
+	Activity        bool           // Whether activity other than completing tasks (e.g. comments) is reported.
+	AsanaToken      string         // Asana Personal Access Token.
+	AllAssignees    bool           // Report tasks assigned to anyone, not just the authenticated user.
+	Workspaces      []string       // Names or GIDs of the workspaces to report on.
+	AllWorkspaces   bool           // Report on all workspaces of the authenticated user.
+	Projects        []string       // If any, only projects whose names match one of these patterns are used.
+	ExcludeProjects []string       // Projects whose names match any of these patterns are skipped.
+	Teams           []string       // If any, only projects whose team names match one of these patterns are used.
+	ExcludeTeams    []string       // Projects whose team names match any of these patterns are skipped.
+	Sections        []string       // If any, only tasks in sections whose names match one of these patterns are used.
+	ExcludeSections []string       // Tasks in sections whose names match any of these patterns are skipped.
+	BlockedSections []string       // Tasks in sections whose names match any of these patterns are blocked.
+	BlockedTags     []string       // Tasks with tags whose names match any of these patterns are blocked.
+	BlockedFields   []string       // Tasks whose enum custom fields match any of these NAME=VALUE patterns are blocked.
+	BlockedByDeps   bool           // Tasks with incomplete dependencies are blocked.
+	IncludeArchived bool           // Use archived projects, which are skipped by default.
+	Planned         []string       // If any, only incomplete tasks matching one of these policies are planned.
+	DueWithin       int            // Number of days after today within which tasks are due for the "due" policy.
+	PlannedSections []string       // Patterns of section names for the "section" policy.
+	MaxAttempts     int            // Maximum number of attempts for each request, including the first.
+	MaxRetryWait    time.Duration  // Maximum time to wait between two attempts of a request.
+	Concurrency     int            // Maximum number of projects to retrieve tasks for concurrently.
 }
 
 /*
-Get returns the current configuration of the standup-reporter.
+Get returns the current configuration of the standup-reporter.  Days and the window are calculated in the given
+location, or in the local timezone if it is nil.
 */
-func Get(days int) *Configuration {
-	t := time.Now().Local()
+func Get(days int, calendar *Calendar, location *time.Location) *Configuration {
+	if location == nil {
+		location = time.Local
+	}
+	t := time.Now().In(location)
 	if days == 0 {
-		days = calculateDays(t)
+		days = calculateDays(t, calendar)
 	}
-	todayMidnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
-	var wg sync.WaitGroup
+	todayMidnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, location)
 	return &Configuration{
+		Location:      location,
 		TodayMidnight: todayMidnight,
-		EarliestDate:  todayMidnight.AddDate(0, 0, -days).Format(time.RFC3339),
-		WG:            &wg,
+		EarliestDate:  todayMidnight.AddDate(0, 0, -days),
+		LatestDate:    todayMidnight,
 	}
 }
 
-func calculateDays(t time.Time) int {
-	if t.Weekday() == time.Monday { // account for weekend
-		return 3
+/*
+calculateDays returns the number of days to go back to the previous working day (e.g. 3 on a Monday, to account for the
+weekend).  A nil calendar has working days from Monday to Friday and no holidays.
+*/
+func calculateDays(t time.Time, calendar *Calendar) int {
+	if calendar == nil {
+		calendar, _ = NewCalendar(nil, nil) //nolint:errcheck
+	}
+	for days := 1; days <= maxLookback; days++ {
+		if calendar.IsWorkDay(t.AddDate(0, 0, -days)) {
+			return days
+		}
 	}
 	return 1
 }
+
+/*
+LoadLocation returns the timezone with the given IANA name (e.g. "America/New_York"), or the local timezone if the name
+is empty or "Local".
+*/
+func LoadLocation(name string) (*time.Location, error) {
+	if name == "" || name == "Local" {
+		return time.Local, nil
+	}
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, xerrors.Errorf("invalid timezone %q: %w", name, err)
+	}
+	return location, nil
+}
+
+// Planned-task policies.
+const (
+	PlannedDue     = "due"     // Tasks due today or overdue (or due within DueWithin days).
+	PlannedStarted = "started" // Tasks whose start date is today or earlier.
+	PlannedSection = "section" // Tasks in a section matching PlannedSections (by default "In Progress").
+)
+
+/*
+PlannedPolicies returns the names of the planned-task policies.
+*/
+func PlannedPolicies() []string {
+	return []string{PlannedDue, PlannedStarted, PlannedSection}
+}
+
+// Ways of reporting subtasks.
+const (
+	SubtasksOff    = "off"    // Subtasks aren't retrieved.
+	SubtasksNest   = "nest"   // Subtasks are listed under their parent task.
+	SubtasksRollUp = "rollup" // Parent tasks are listed with the number of their completed subtasks.
+)
+
+/*
+SubtaskModes returns the ways subtasks can be reported.
+*/
+func SubtaskModes() []string {
+	return []string{SubtasksOff, SubtasksNest, SubtasksRollUp}
+}
@@ -21,8 +21,102 @@ func TestCalculateDays(t *testing.T) {
 	for _, tc := range testCases {
 		tc := tc
 		t.Run(tc.name, func(t *testing.T) {
-			actual := calculateDays(tc.time)
+			actual := calculateDays(tc.time, nil)
 			assert.Equal(t, tc.expected, actual)
 		})
 	}
 }
+
+func TestCalculateDaysCalendar(t *testing.T) {
+	sundayToThursday, err := NewCalendar([]string{"sun-thu"}, nil)
+	assert.Nil(t, err)
+	withHoliday, err := NewCalendar(nil, []string{"2019-07-04"})
+	assert.Nil(t, err)
+	fourDays, err := NewCalendar([]string{"mon-thu"}, nil)
+	assert.Nil(t, err)
+	none, err := NewCalendar([]string{""}, nil)
+	assert.Nil(t, err)
+	testCases := []struct {
+		name     string
+		time     time.Time
+		calendar *Calendar
+		expected int
+	}{
+		{name: "SundayAfterThursday", time: time.Date(2019, time.July, 14, 9, 0, 0, 0, time.UTC), calendar: sundayToThursday, expected: 3}, //nolint:lll
+		{name: "Monday", time: time.Date(2019, time.July, 15, 9, 0, 0, 0, time.UTC), calendar: sundayToThursday, expected: 1},
+		{name: "AfterHoliday", time: time.Date(2019, time.July, 5, 9, 0, 0, 0, time.UTC), calendar: withHoliday, expected: 2},
+		{name: "MondayAfterFourDayWeek", time: time.Date(2019, time.July, 15, 9, 0, 0, 0, time.UTC), calendar: fourDays, expected: 4}, //nolint:lll
+		{name: "NoWorkDays", time: time.Date(2019, time.July, 15, 9, 0, 0, 0, time.UTC), calendar: none, expected: 1},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, calculateDays(tc.time, tc.calendar))
+		})
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	todayMidnight := time.Date(2019, time.June, 5, 0, 0, 0, 0, time.Local) // Wednesday
+	testCases := []struct {
+		name        string
+		expr        string
+		expected    time.Time
+		expectedDay bool
+	}{
+		{name: "Date", expr: "2019-05-20", expected: time.Date(2019, time.May, 20, 0, 0, 0, 0, time.Local), expectedDay: true}, //nolint:lll
+		{name: "RFC3339", expr: "2019-05-20T09:30:00Z", expected: time.Date(2019, time.May, 20, 9, 30, 0, 0, time.UTC)},
+		{name: "Today", expr: "today", expected: todayMidnight, expectedDay: true},
+		{name: "Yesterday", expr: "Yesterday", expected: todayMidnight.AddDate(0, 0, -1), expectedDay: true},
+		{name: "Weekday", expr: "monday", expected: todayMidnight.AddDate(0, 0, -2), expectedDay: true},
+		{name: "LastWeekday", expr: "last  Friday", expected: todayMidnight.AddDate(0, 0, -5), expectedDay: true},
+		{name: "SameWeekday", expr: "last wednesday", expected: todayMidnight.AddDate(0, 0, -7), expectedDay: true},
+		{name: "DaysAgo", expr: "3 days ago", expected: todayMidnight.AddDate(0, 0, -3), expectedDay: true},
+		{name: "WeeksAgo", expr: "2 weeks ago", expected: todayMidnight.AddDate(0, 0, -14), expectedDay: true},
+		{name: "AMonthAgo", expr: "a month ago", expected: todayMidnight.AddDate(0, -1, 0), expectedDay: true},
+		{name: "LastWeek", expr: "last week", expected: todayMidnight.AddDate(0, 0, -7), expectedDay: true},
+		{name: "LastYear", expr: "last year", expected: todayMidnight.AddDate(-1, 0, 0), expectedDay: true},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			actual, day, err := parseDate(tc.expr, todayMidnight)
+			assert.Nil(t, err)
+			assert.True(t, tc.expected.Equal(actual), actual)
+			assert.Equal(t, tc.expectedDay, day)
+		})
+	}
+}
+
+func TestParseDateInvalid(t *testing.T) {
+	for _, expr := range []string{"", "soon", "2019-06-31", "last decade", "-1 days ago"} {
+		_, _, err := parseDate(expr, time.Date(2019, time.June, 5, 0, 0, 0, 0, time.Local))
+		assert.Error(t, err, expr)
+	}
+}
+
+func TestParseICSEvents(t *testing.T) {
+	lines := []string{
+		"BEGIN:VCALENDAR",
+		"BEGIN:VEVENT",
+		"DTSTART;VALUE=DATE:20191225",
+		"DTEND;VALUE=DATE:20191227",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"SUMMARY:No start",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"DTSTART;VALUE=DATE:20200101",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}
+	events, err := parseICSEvents(lines)
+	assert.Nil(t, err)
+	expected := []icsEvent{
+		{start: time.Date(2019, time.December, 25, 0, 0, 0, 0, time.UTC), end: time.Date(2019, time.December, 27, 0, 0, 0, 0, time.UTC)}, //nolint:lll
+		{start: time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	assert.Equal(t, expected, events)
+	_, err = parseICSEvents([]string{"BEGIN:VEVENT", "DTSTART:tomorrow", "END:VEVENT"})
+	assert.Error(t, err)
+}
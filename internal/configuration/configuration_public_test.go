@@ -1,7 +1,12 @@
 package configuration_test
 
 import (
-	"sync"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
 	"time"
 
@@ -13,18 +18,18 @@ import (
 func TestGet1Day(t *testing.T) {
 	assert := assert.New(t)
 	const days = 1
-	config := configuration.Get(days)
+	config := configuration.Get(days, nil, nil)
 	now := time.Now().Local()
 	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
 	assert.Equal(midnight, config.TodayMidnight)
-	assert.Equal(midnight.AddDate(0, 0, -days).Format(time.RFC3339), config.EarliestDate)
-	assert.IsType(&sync.WaitGroup{}, config.WG)
+	assert.Equal(midnight.AddDate(0, 0, -days), config.EarliestDate)
+	assert.Equal(midnight, config.LatestDate)
 }
 
 func TestGet0Day(t *testing.T) {
 	assert := assert.New(t)
 	const days = 0
-	config := configuration.Get(days)
+	config := configuration.Get(days, nil, nil)
 	now := time.Now().Local()
 	expectedDays := 1
 	if now.Weekday() == time.Monday {
@@ -32,6 +37,540 @@ func TestGet0Day(t *testing.T) {
 	}
 	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
 	assert.Equal(midnight, config.TodayMidnight)
-	assert.Equal(midnight.AddDate(0, 0, -expectedDays).Format(time.RFC3339), config.EarliestDate)
-	assert.IsType(&sync.WaitGroup{}, config.WG)
+	assert.Equal(midnight.AddDate(0, 0, -expectedDays), config.EarliestDate)
+	assert.Equal(midnight, config.LatestDate)
+}
+
+func writeFile(t *testing.T, text string) string {
+	f, err := ioutil.TempFile("", "config-*.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(text); err != nil {
+		t.Fatal(err)
+	}
+	return f.Name()
+}
+
+func TestLoadFile(t *testing.T) {
+	path := writeFile(t, `
+format: markdown
+days: 2
+work_days: [sun-thu]
+holidays: [2019-12-25, holidays.ics]
+asana:
+  token: 123abc
+  workspaces: [Workspace 1]
+  projects:
+    - Eng*
+    - /^Ops$/
+  include_archived: true
+  max_retry_wait: 1m
+  concurrency: 8
+`)
+	defer os.Remove(path)
+	file, err := configuration.LoadFile(path, true)
+	assert.Nil(t, err)
+	expected := &configuration.File{
+		Format:   "markdown",
+		Days:     2,
+		WorkDays: []string{"sun-thu"},
+		Holidays: []string{"2019-12-25", "holidays.ics"},
+		Asana: configuration.AsanaFile{
+			Token:           "123abc",
+			Workspaces:      []string{"Workspace 1"},
+			Projects:        []string{"Eng*", "/^Ops$/"},
+			IncludeArchived: true,
+			MaxRetryWait:    time.Minute,
+			Concurrency:     8,
+		},
+	}
+	assert.Equal(t, expected, file)
+}
+
+func TestLoadFileUnknownSetting(t *testing.T) {
+	path := writeFile(t, "asana:\n  tokn: 123abc\n")
+	defer os.Remove(path)
+	_, err := configuration.LoadFile(path, true)
+	assert.Error(t, err)
+}
+
+func TestLoadFileMissing(t *testing.T) {
+	path := filepath.Join(os.TempDir(), "standup-reporter-missing.yaml")
+	testCases := []struct {
+		name     string
+		required bool
+	}{
+		{name: "Required", required: true},
+		{name: "Optional", required: false},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			file, err := configuration.LoadFile(path, tc.required)
+			if tc.required {
+				assert.Error(t, err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, &configuration.File{}, file)
+		})
+	}
+}
+
+func TestDefaultFilePath(t *testing.T) {
+	original, set := os.LookupEnv("XDG_CONFIG_HOME")
+	defer func() {
+		if set {
+			os.Setenv("XDG_CONFIG_HOME", original) //nolint:errcheck
+		} else {
+			os.Unsetenv("XDG_CONFIG_HOME") //nolint:errcheck
+		}
+	}()
+	assert.Nil(t, os.Setenv("XDG_CONFIG_HOME", filepath.Join("home", "config")))
+	expected := filepath.Join("home", "config", "standup-reporter", "config.yaml")
+	assert.Equal(t, expected, configuration.DefaultFilePath())
+}
+
+func TestSecretResolve(t *testing.T) {
+	path := writeFile(t, "file-token\n")
+	defer os.Remove(path)
+	assert.Nil(t, os.Chmod(path, 0600))
+	testCases := []struct {
+		name     string
+		secret   configuration.Secret
+		expected string
+	}{
+		{name: "None", secret: configuration.Secret{}, expected: ""},
+		{
+			name:     "Value",
+			secret:   configuration.Secret{Value: "token", File: path, Command: "echo command-token"},
+			expected: "token",
+		},
+		{name: "File", secret: configuration.Secret{File: path, Command: "echo command-token"}, expected: "file-token"},
+		{name: "Command", secret: configuration.Secret{Command: "echo command-token"}, expected: "command-token"},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.secret.Command != "" && runtime.GOOS == "windows" {
+				t.Skip("commands are run with sh")
+			}
+			var warnings bytes.Buffer
+			actual, err := tc.secret.Resolve(&warnings)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expected, actual)
+			assert.Empty(t, warnings.String())
+		})
+	}
+}
+
+func TestResolveSecret(t *testing.T) {
+	path := writeFile(t, "file-token\n")
+	defer os.Remove(path)
+	assert.Nil(t, os.Chmod(path, 0600))
+	testCases := []struct {
+		name     string
+		secrets  []configuration.Secret
+		expected string
+	}{
+		{name: "None", secrets: nil, expected: ""},
+		{name: "AllEmpty", secrets: []configuration.Secret{{}, {}}, expected: ""},
+		{
+			name:     "FirstSet",
+			secrets:  []configuration.Secret{{File: path}, {Value: "token"}},
+			expected: "file-token",
+		},
+		{
+			name:     "SkipsEmpty",
+			secrets:  []configuration.Secret{{}, {File: path}, {Value: "token"}},
+			expected: "file-token",
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := configuration.ResolveSecret(ioutil.Discard, tc.secrets...)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestSecretResolveWorldReadableFile(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file permissions aren't checked on Windows")
+	}
+	path := writeFile(t, "file-token")
+	defer os.Remove(path)
+	assert.Nil(t, os.Chmod(path, 0644))
+	var warnings bytes.Buffer
+	actual, err := configuration.Secret{File: path}.Resolve(&warnings)
+	assert.Nil(t, err)
+	assert.Equal(t, "file-token", actual)
+	assert.Contains(t, warnings.String(), "is readable by all users")
+}
+
+func TestSecretResolveFailure(t *testing.T) {
+	empty := writeFile(t, " \n")
+	defer os.Remove(empty)
+	testCases := []struct {
+		name   string
+		secret configuration.Secret
+	}{
+		{name: "MissingFile", secret: configuration.Secret{File: filepath.Join(os.TempDir(), "standup-reporter-missing")}},
+		{name: "EmptyFile", secret: configuration.Secret{File: empty}},
+		{name: "FailedCommand", secret: configuration.Secret{Command: "exit 1"}},
+		{name: "EmptyCommand", secret: configuration.Secret{Command: "true"}},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.secret.Command != "" && runtime.GOOS == "windows" {
+				t.Skip("commands are run with sh")
+			}
+			_, err := tc.secret.Resolve(ioutil.Discard)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func windowConfiguration() *configuration.Configuration {
+	todayMidnight := time.Date(2019, time.June, 5, 0, 0, 0, 0, time.Local) // Wednesday
+	return &configuration.Configuration{
+		TodayMidnight: todayMidnight,
+		EarliestDate:  todayMidnight.AddDate(0, 0, -1),
+		LatestDate:    todayMidnight,
+	}
+}
+
+func TestSetWindow(t *testing.T) {
+	testCases := []struct {
+		name             string
+		since            string
+		until            string
+		expectedEarliest time.Time
+		expectedLatest   time.Time
+	}{
+		{
+			name:             "Default",
+			expectedEarliest: time.Date(2019, time.June, 4, 0, 0, 0, 0, time.Local),
+			expectedLatest:   time.Date(2019, time.June, 5, 0, 0, 0, 0, time.Local),
+		},
+		{
+			name:             "Since",
+			since:            "last friday",
+			expectedEarliest: time.Date(2019, time.May, 31, 0, 0, 0, 0, time.Local),
+			expectedLatest:   time.Date(2019, time.June, 5, 0, 0, 0, 0, time.Local),
+		},
+		{
+			name:             "SinceAndUntilDates",
+			since:            "2019-05-20",
+			until:            "2019-05-31",
+			expectedEarliest: time.Date(2019, time.May, 20, 0, 0, 0, 0, time.Local),
+			expectedLatest:   time.Date(2019, time.June, 1, 0, 0, 0, 0, time.Local),
+		},
+		{
+			name:             "UntilOnly",
+			until:            "2019-05-31",
+			expectedEarliest: time.Date(2019, time.May, 31, 0, 0, 0, 0, time.Local),
+			expectedLatest:   time.Date(2019, time.June, 1, 0, 0, 0, 0, time.Local),
+		},
+		{
+			name:             "UntilTime",
+			since:            "2 weeks ago",
+			until:            "2019-06-04T12:00:00Z",
+			expectedEarliest: time.Date(2019, time.May, 22, 0, 0, 0, 0, time.Local),
+			expectedLatest:   time.Date(2019, time.June, 4, 12, 0, 0, 0, time.UTC),
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			config := windowConfiguration()
+			err := config.SetWindow(tc.since, tc.until)
+			assert.Nil(t, err)
+			assert.True(t, tc.expectedEarliest.Equal(config.EarliestDate), config.EarliestDate)
+			assert.True(t, tc.expectedLatest.Equal(config.LatestDate), config.LatestDate)
+		})
+	}
+}
+
+func TestSetWindowFailure(t *testing.T) {
+	testCases := []struct {
+		name  string
+		since string
+		until string
+	}{
+		{name: "InvalidSince", since: "the other day"},
+		{name: "InvalidUntil", until: "2019-13-01"},
+		{name: "SinceAfterUntil", since: "today", until: "yesterday"},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			config := windowConfiguration()
+			err := config.SetWindow(tc.since, tc.until)
+			assert.Error(t, err)
+			assert.Equal(t, windowConfiguration(), config)
+		})
+	}
+}
+
+func TestSetToday(t *testing.T) {
+	now := time.Date(2019, time.June, 5, 9, 15, 0, 0, time.Local)
+	testCases := []struct {
+		name          string
+		mode          string
+		cutoff        string
+		until         string
+		expectedEnd   time.Time
+		expectedMerge bool
+	}{
+		{name: "Off", mode: configuration.TodayOff},
+		{name: "Separate", mode: configuration.TodaySeparate, expectedEnd: now},
+		{name: "Merge", mode: configuration.TodayMerge, expectedEnd: now, expectedMerge: true},
+		{
+			name:        "Cutoff",
+			mode:        configuration.TodaySeparate,
+			cutoff:      "09:00",
+			expectedEnd: time.Date(2019, time.June, 5, 9, 0, 0, 0, time.Local),
+		},
+		{name: "MidnightCutoff", mode: configuration.TodaySeparate, cutoff: "00:00"},
+		{name: "PastWindow", mode: configuration.TodaySeparate, until: "2019-05-31"},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			config := windowConfiguration()
+			assert.Nil(t, config.SetWindow("2019-05-20", tc.until))
+			err := config.SetToday(tc.mode, tc.cutoff, now)
+			assert.Nil(t, err)
+			assert.True(t, tc.expectedEnd.Equal(config.TodayEnd), config.TodayEnd)
+			assert.Equal(t, tc.expectedMerge, config.MergeToday)
+		})
+	}
+}
+
+func TestSetTodayFailure(t *testing.T) {
+	now := time.Date(2019, time.June, 5, 9, 15, 0, 0, time.Local)
+	config := windowConfiguration()
+	assert.Error(t, config.SetToday("always", "", now))
+	assert.Error(t, config.SetToday(configuration.TodayMerge, "9am", now))
+}
+
+func TestNewCalendarWorkDays(t *testing.T) {
+	monday := time.Date(2019, time.July, 15, 0, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		name     string
+		workDays []string
+		expected []bool // Sunday to Saturday
+	}{
+		{name: "Default", workDays: nil, expected: []bool{false, true, true, true, true, true, false}},
+		{name: "Range", workDays: []string{"sun-thu"}, expected: []bool{true, true, true, true, true, false, false}},
+		{name: "WrappingRange", workDays: []string{"Fri-Mon"}, expected: []bool{true, true, false, false, false, true, true}},
+		{name: "List", workDays: []string{"monday, tue,thu"}, expected: []bool{false, true, true, false, true, false, false}},
+		{name: "Repeated", workDays: []string{"mon", "wed"}, expected: []bool{false, true, false, true, false, false, false}},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			calendar, err := configuration.NewCalendar(tc.workDays, nil)
+			assert.Nil(t, err)
+			for i, expected := range tc.expected {
+				day := monday.AddDate(0, 0, i-1)
+				assert.Equal(t, expected, calendar.IsWorkDay(day), day.Weekday().String())
+			}
+		})
+	}
+}
+
+func TestNewCalendarHolidays(t *testing.T) {
+	path := writeFile(t, strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"BEGIN:VEVENT",
+		"DTSTART;VALUE=DATE:20191225",
+		"SUMMARY:Christmas",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"DTSTART;VALUE=DATE:20191128",
+		"DTEND;VALUE=DATE:20191130",
+		"SUMMARY:Thanksgiving",
+		" break",
+		"BEGIN:VALARM",
+		"END:VALARM",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n"))
+	defer os.Remove(path)
+	icsPath := path + ".ics"
+	assert.Nil(t, os.Rename(path, icsPath))
+	defer os.Remove(icsPath)
+	calendar, err := configuration.NewCalendar(nil, []string{"2019-07-04", icsPath})
+	assert.Nil(t, err)
+	testCases := []struct {
+		date     time.Time
+		expected bool
+	}{
+		{date: time.Date(2019, time.July, 4, 0, 0, 0, 0, time.UTC), expected: false},
+		{date: time.Date(2019, time.July, 5, 0, 0, 0, 0, time.UTC), expected: true},
+		{date: time.Date(2019, time.November, 27, 0, 0, 0, 0, time.UTC), expected: true},
+		{date: time.Date(2019, time.November, 28, 0, 0, 0, 0, time.UTC), expected: false},
+		{date: time.Date(2019, time.November, 29, 0, 0, 0, 0, time.UTC), expected: false},
+		{date: time.Date(2019, time.December, 25, 0, 0, 0, 0, time.UTC), expected: false},
+	}
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, calendar.IsWorkDay(tc.date), tc.date.Format("2006-01-02"))
+	}
+}
+
+func TestNewCalendarFailure(t *testing.T) {
+	testCases := []struct {
+		name     string
+		workDays []string
+		holidays []string
+	}{
+		{name: "InvalidWorkDay", workDays: []string{"mo"}},
+		{name: "InvalidRange", workDays: []string{"mon-xyz"}},
+		{name: "InvalidHoliday", holidays: []string{"July 4"}},
+		{name: "MissingCalendar", holidays: []string{filepath.Join(os.TempDir(), "standup-reporter-missing.ics")}},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := configuration.NewCalendar(tc.workDays, tc.holidays)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestStateSaveLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "standup-reporter")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "standup-reporter", "state.json")
+	state, err := configuration.LoadState(path)
+	assert.Nil(t, err)
+	assert.Equal(t, &configuration.State{}, state)
+	config := windowConfiguration()
+	now := config.TodayMidnight.Add(9 * time.Hour)
+	assert.True(t, state.Record(config, now))
+	assert.Nil(t, state.Save(path))
+	loaded, err := configuration.LoadState(path)
+	assert.Nil(t, err)
+	assert.True(t, now.Equal(loaded.LastRun))
+	assert.True(t, config.EarliestDate.Equal(loaded.WindowStart))
+	assert.True(t, config.LatestDate.Equal(loaded.WindowEnd))
+}
+
+func TestLoadStateInvalid(t *testing.T) {
+	path := writeFile(t, "{")
+	defer os.Remove(path)
+	_, err := configuration.LoadState(path)
+	assert.Error(t, err)
+}
+
+func TestStateRecordPastWindow(t *testing.T) {
+	config := windowConfiguration()
+	assert.Nil(t, config.SetWindow("2019-05-20", "2019-05-31"))
+	state := &configuration.State{}
+	assert.False(t, state.Record(config, config.TodayMidnight))
+	assert.Equal(t, &configuration.State{}, state)
+}
+
+func TestSinceLast(t *testing.T) {
+	todayMidnight := windowConfiguration().TodayMidnight
+	testCases := []struct {
+		name             string
+		state            *configuration.State
+		expectedChanged  bool
+		expectedEarliest time.Time
+	}{
+		{
+			name:             "NoRun",
+			state:            &configuration.State{},
+			expectedEarliest: todayMidnight.AddDate(0, 0, -1),
+		},
+		{
+			name: "SkippedStandup",
+			state: &configuration.State{
+				WindowStart: todayMidnight.AddDate(0, 0, -3),
+				WindowEnd:   todayMidnight.AddDate(0, 0, -2),
+			},
+			expectedChanged:  true,
+			expectedEarliest: todayMidnight.AddDate(0, 0, -2),
+		},
+		{
+			name: "RunToday",
+			state: &configuration.State{
+				WindowStart: todayMidnight.AddDate(0, 0, -3),
+				WindowEnd:   todayMidnight,
+			},
+			expectedChanged:  true,
+			expectedEarliest: todayMidnight.AddDate(0, 0, -3),
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			config := windowConfiguration()
+			assert.Equal(t, tc.expectedChanged, config.SinceLast(tc.state))
+			assert.Equal(t, tc.expectedEarliest, config.EarliestDate)
+			assert.Equal(t, todayMidnight, config.LatestDate)
+		})
+	}
+}
+
+func TestDefaultStatePath(t *testing.T) {
+	original, set := os.LookupEnv("XDG_STATE_HOME")
+	defer func() {
+		if set {
+			os.Setenv("XDG_STATE_HOME", original) //nolint:errcheck
+		} else {
+			os.Unsetenv("XDG_STATE_HOME") //nolint:errcheck
+		}
+	}()
+	assert.Nil(t, os.Setenv("XDG_STATE_HOME", filepath.Join("home", "state")))
+	expected := filepath.Join("home", "state", "standup-reporter", "state.json")
+	assert.Equal(t, expected, configuration.DefaultStatePath())
+}
+
+func TestGetLocation(t *testing.T) {
+	assert := assert.New(t)
+	location, err := configuration.LoadLocation("Asia/Tokyo")
+	assert.Nil(err)
+	config := configuration.Get(1, nil, location)
+	now := time.Now().In(location)
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, location)
+	assert.Equal(location, config.Location)
+	assert.Equal(midnight, config.TodayMidnight)
+	assert.Equal(midnight.AddDate(0, 0, -1), config.EarliestDate)
+	assert.Equal(midnight, config.LatestDate)
+}
+
+func TestLoadLocation(t *testing.T) {
+	testCases := []struct {
+		name     string
+		timezone string
+		expected string
+	}{
+		{name: "Empty", timezone: "", expected: time.Local.String()},
+		{name: "Local", timezone: "Local", expected: time.Local.String()},
+		{name: "UTC", timezone: "UTC", expected: "UTC"},
+		{name: "IANA", timezone: "America/New_York", expected: "America/New_York"},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			location, err := configuration.LoadLocation(tc.timezone)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expected, location.String())
+		})
+	}
+}
+
+func TestLoadLocationInvalid(t *testing.T) {
+	_, err := configuration.LoadLocation("Mars/Olympus_Mons")
+	assert.Error(t, err)
 }
@@ -0,0 +1,105 @@
+package configuration
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"golang.org/x/xerrors"
+	"gopkg.in/yaml.v2"
+)
+
+/*
+File defines the settings which can be read from a YAML configuration file.  Settings missing from the file are left
+as their zero value, in which case the default (or the value of the corresponding flag or environment variable) is
+used.
+*/
+type File struct {
+	Sources     []string  `yaml:"sources"`      // Names of the enabled sources.
+	Format      string    `yaml:"format"`       // Output format of the report.
+	Template    string    `yaml:"template"`     // Path of a text/template used to render the report.
+	Sort        []string  `yaml:"sort"`         // Keys the items of each section are sorted by.
+	GroupBy     string    `yaml:"group_by"`     // Field the items of each section are grouped by.
+	Subtasks    string    `yaml:"subtasks"`     // How subtasks are reported.
+	Activity    bool      `yaml:"activity"`     // Report activity other than completing tasks (e.g. comments).
+	Days        int       `yaml:"days"`         // Number of days to go back to collect completed tasks.
+	WorkDays    []string  `yaml:"work_days"`    // Working days of the week (e.g. "mon-fri").
+	Holidays    []string  `yaml:"holidays"`     // Holidays (YYYY-MM-DD) or paths of .ics files of holidays.
+	Since       string    `yaml:"since"`        // Start of the window for completed tasks.
+	Until       string    `yaml:"until"`        // End of the window for completed tasks.
+	SinceLast   bool      `yaml:"since_last"`   // Report tasks completed since the window of the last run.
+	StateFile   string    `yaml:"state_file"`   // Path of the file the last run is recorded in.
+	Timezone    string    `yaml:"timezone"`     // IANA name of the timezone in which days start (e.g. "America/New_York").
+	Today       string    `yaml:"today"`        // How tasks completed today are reported ("off", "merge" or "separate").
+	TodayCutoff string    `yaml:"today_cutoff"` // Time of day (HH:MM) until which tasks completed today are reported.
+	Asana       AsanaFile `yaml:"asana"`        // Settings of the Asana source.
+}
+
+/*
+AsanaFile defines the settings of the Asana source which can be read from a configuration file.
+*/
+type AsanaFile struct {
+	Token           string        `yaml:"token"`            // Asana Personal Access Token.
+	TokenFile       string        `yaml:"token_file"`       // Path of a file containing the token.
+	TokenCmd        string        `yaml:"token_cmd"`        // Shell command which prints the token.
+	AllAssignees    bool          `yaml:"all_assignees"`    // Report tasks assigned to anyone.
+	Workspaces      []string      `yaml:"workspaces"`       // Names or GIDs of the workspaces to report on.
+	AllWorkspaces   bool          `yaml:"all_workspaces"`   // Report on all workspaces.
+	Projects        []string      `yaml:"projects"`         // Patterns of project names to use.
+	ExcludeProjects []string      `yaml:"exclude_projects"` // Patterns of project names to skip.
+	Teams           []string      `yaml:"teams"`            // Patterns of team names whose projects are used.
+	ExcludeTeams    []string      `yaml:"exclude_teams"`    // Patterns of team names whose projects are skipped.
+	Sections        []string      `yaml:"sections"`         // Patterns of section names whose tasks are used.
+	ExcludeSections []string      `yaml:"exclude_sections"` // Patterns of section names whose tasks are skipped.
+	BlockedSections []string      `yaml:"blocked_sections"` // Patterns of section names whose tasks are blockers.
+	BlockedTags     []string      `yaml:"blocked_tags"`     // Patterns of tag names whose tasks are blockers.
+	BlockedFields   []string      `yaml:"blocked_fields"`   // NAME=VALUE patterns of enum fields of blockers.
+	BlockedByDeps   bool          `yaml:"blocked_by_deps"`  // Report tasks with incomplete dependencies as blockers.
+	IncludeArchived bool          `yaml:"include_archived"` // Use archived projects.
+	Planned         []string      `yaml:"planned"`          // Planned-task policies ("due", "started" or "section").
+	DueWithin       int           `yaml:"due_within"`       // Number of days within which due tasks are planned.
+	PlannedSections []string      `yaml:"planned_sections"` // Patterns of section names whose tasks are planned.
+	MaxAttempts     int           `yaml:"max_attempts"`     // Maximum number of attempts for each request.
+	MaxRetryWait    time.Duration `yaml:"max_retry_wait"`   // Maximum time to wait between two attempts of a request.
+	Concurrency     int           `yaml:"concurrency"`      // Maximum number of projects to retrieve concurrently.
+}
+
+/*
+DefaultFilePath returns the path of the configuration file used when none is given, which is
+"standup-reporter/config.yaml" in $XDG_CONFIG_HOME (or ~/.config if it isn't set).  An empty path is returned if the
+home directory can't be determined.
+*/
+func DefaultFilePath() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "standup-reporter", "config.yaml")
+}
+
+/*
+LoadFile reads the configuration file at the given path.  If the file doesn't exist and isn't required, empty settings
+are returned.  Unknown settings are rejected, so typos don't go unnoticed.
+*/
+func LoadFile(path string, required bool) (*File, error) {
+	file := &File{}
+	if path == "" {
+		return file, nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) && !required {
+			return file, nil
+		}
+		return nil, xerrors.Errorf("error reading configuration file: %w", err)
+	}
+	if err := yaml.UnmarshalStrict(data, file); err != nil {
+		return nil, xerrors.Errorf("error parsing configuration file %s: %w", path, err)
+	}
+	return file, nil
+}
@@ -0,0 +1,101 @@
+package configuration
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+/*
+Secret defines the ways a secret (e.g. an access token) can be provided, so it doesn't have to be passed on the command
+line where it is visible to other users and saved in the shell history.
+*/
+type Secret struct {
+	Value   string // Secret given directly.
+	File    string // Path of a file containing the secret.
+	Command string // Shell command which prints the secret (e.g. "pass show asana").
+}
+
+/*
+Resolve returns the secret, which is the value if it is set, or else the contents of the file if it is set, or else the
+output of the command if it is set.  Surrounding whitespace is trimmed from the file contents and command output.  An
+empty secret is returned if none are set.  Warnings (e.g. about a file which is readable by other users) are written to
+warnings.
+*/
+func (s Secret) Resolve(warnings io.Writer) (string, error) {
+	switch {
+	case s.Value != "":
+		return s.Value, nil
+	case s.File != "":
+		return readSecretFile(s.File, warnings)
+	case s.Command != "":
+		return runSecretCommand(s.Command)
+	default:
+		return "", nil
+	}
+}
+
+/*
+ResolveSecret resolves the first of the secrets which has any of its value, file or command set, so a secret provided in
+several places (e.g. command-line flags, environment variables and a configuration file) is taken from the one with
+the highest precedence, given first.  An empty secret is returned if none are set.
+*/
+func ResolveSecret(warnings io.Writer, secrets ...Secret) (string, error) {
+	for _, s := range secrets {
+		if s != (Secret{}) {
+			return s.Resolve(warnings)
+		}
+	}
+	return "", nil
+}
+
+func readSecretFile(path string, warnings io.Writer) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", xerrors.Errorf("error reading secret file: %w", err)
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0004 != 0 {
+		fmt.Fprintf(warnings, "warning: %s is readable by all users, restrict it with \"chmod 600 %s\"\n", path, path)
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", xerrors.Errorf("error reading secret file: %w", err)
+	}
+	secret := strings.TrimSpace(string(data))
+	if secret == "" {
+		return "", xerrors.Errorf("secret file %s is empty", path)
+	}
+	return secret, nil
+}
+
+/*
+runSecretCommand runs the command with the shell.  The command's stdin and stderr are those of the standup-reporter, so
+the command can prompt for a passphrase.
+*/
+func runSecretCommand(command string) (string, error) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command) //nolint:gosec
+	} else {
+		cmd = exec.Command("sh", "-c", command) //nolint:gosec
+	}
+	var stdout bytes.Buffer
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", xerrors.Errorf("error running secret command %q: %w", command, err)
+	}
+	secret := strings.TrimSpace(stdout.String())
+	if secret == "" {
+		return "", xerrors.Errorf("secret command %q printed nothing", command)
+	}
+	return secret, nil
+}
@@ -0,0 +1,127 @@
+package configuration
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+/*
+State is the information the standup-reporter remembers between runs.
+*/
+type State struct {
+	LastRun     time.Time `json:"last_run"`     // Time of the last successful run.
+	WindowStart time.Time `json:"window_start"` // Start of the window reported by the last successful run.
+	WindowEnd   time.Time `json:"window_end"`   // End of the window reported by the last successful run.
+}
+
+/*
+DefaultStatePath returns the path of the state file used when none is given, which is "standup-reporter/state.json" in
+$XDG_STATE_HOME (or ~/.local/state if it isn't set).  An empty path is returned if the home directory can't be
+determined.
+*/
+func DefaultStatePath() string {
+	dir := os.Getenv("XDG_STATE_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".local", "state")
+	}
+	return filepath.Join(dir, "standup-reporter", "state.json")
+}
+
+/*
+LoadState reads the state file at the given path.  An empty state is returned if the file doesn't exist.
+*/
+func LoadState(path string) (*State, error) {
+	state := &State{}
+	if path == "" {
+		return state, nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return state, nil
+		}
+		return nil, xerrors.Errorf("error reading state file: %w", err)
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, xerrors.Errorf("error parsing state file %s: %w", path, err)
+	}
+	return state, nil
+}
+
+/*
+Save writes the state file at the given path, creating its directory if needed.  The file is replaced atomically, so
+an interrupted run can't leave a partially written file behind.
+*/
+func (s *State) Save(path string) error {
+	if path == "" {
+		return xerrors.New("error saving state: no state file")
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return xerrors.Errorf("error saving state: %w", err)
+	}
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return xerrors.Errorf("error saving state: %w", err)
+	}
+	f, err := ioutil.TempFile(dir, ".state-*.json")
+	if err != nil {
+		return xerrors.Errorf("error saving state: %w", err)
+	}
+	defer os.Remove(f.Name()) //nolint:errcheck // fails once the file is renamed
+	if _, err := f.Write(data); err != nil {
+		f.Close() //nolint:errcheck,gosec
+		return xerrors.Errorf("error saving state: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return xerrors.Errorf("error saving state: %w", err)
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		return xerrors.Errorf("error saving state: %w", err)
+	}
+	return nil
+}
+
+/*
+Record remembers a successful run at the given time, along with its window.  Only windows ending today are recorded,
+so reports of past windows (e.g. for a sprint review) don't affect the next standup.  It reports whether the run was
+recorded.
+*/
+func (s *State) Record(config *Configuration, now time.Time) bool {
+	if !config.LatestDate.Equal(config.TodayMidnight) {
+		return false
+	}
+	s.LastRun, s.WindowStart, s.WindowEnd = now, config.EarliestDate, config.LatestDate
+	return true
+}
+
+/*
+SinceLast sets the start of the window to the end of the window reported by the last run, so no days are missed when a
+standup is skipped.  When the last run was today, its window is reported again.  It reports whether the start of the
+window was changed, which it isn't if no run was recorded.
+*/
+func (c *Configuration) SinceLast(state *State) bool {
+	switch {
+	case state.WindowEnd.IsZero():
+		return false
+	case state.WindowEnd.Before(c.LatestDate):
+		c.EarliestDate = state.WindowEnd
+	case state.WindowStart.Before(c.LatestDate):
+		c.EarliestDate = state.WindowStart
+	default:
+		return false
+	}
+	if c.Location != nil { // the window was recorded in the timezone of the last run
+		c.EarliestDate = c.EarliestDate.In(c.Location)
+	}
+	return true
+}
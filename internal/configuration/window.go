@@ -0,0 +1,152 @@
+package configuration
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+//nolint:gochecknoglobals
+var agoPattern = regexp.MustCompile(`^(\d+|a|an|one) (day|week|month|year)s? ago$`)
+
+//nolint:gochecknoglobals
+var lastPattern = regexp.MustCompile(`^last (day|week|month|year)$`)
+
+/*
+SetWindow sets the window for which completed tasks are reported to [since, until), where since and until are dates
+accepted by parseDate.  An empty since or until keeps the current start or end of the window, except that when only
+until is given, the window is moved back to end at until while keeping its number of days.  When until is a date rather
+than a time, the window includes that whole day.
+*/
+func (c *Configuration) SetWindow(since, until string) error {
+	earliest, latest := c.EarliestDate, c.LatestDate
+	if since != "" {
+		t, _, err := parseDate(since, c.TodayMidnight)
+		if err != nil {
+			return xerrors.Errorf("invalid since: %w", err)
+		}
+		earliest = t
+	}
+	if until != "" {
+		t, day, err := parseDate(until, c.TodayMidnight)
+		if err != nil {
+			return xerrors.Errorf("invalid until: %w", err)
+		}
+		if day {
+			t = t.AddDate(0, 0, 1)
+		}
+		if since == "" {
+			earliest = t.AddDate(0, 0, -int(math.Round(latest.Sub(earliest).Hours()/24)))
+		}
+		latest = t
+	}
+	if !earliest.Before(latest) {
+		return xerrors.Errorf("since (%s) must be before until (%s)",
+			earliest.Format(time.RFC3339), latest.Format(time.RFC3339))
+	}
+	if c.Location != nil { // times may have been given in another timezone
+		earliest, latest = earliest.In(c.Location), latest.In(c.Location)
+	}
+	c.EarliestDate, c.LatestDate = earliest, latest
+	return nil
+}
+
+/*
+parseDate parses an absolute date (YYYY-MM-DD), an absolute time (RFC3339) or a date relative to today (e.g. "today",
+"yesterday", "fri", "last friday", "2 weeks ago" or "last month").  Weekdays refer to the most recent such day
+before today.  It reports whether the result is a date (i.e. midnight at the start of a day) rather than a time.
+*/
+func parseDate(expr string, todayMidnight time.Time) (time.Time, bool, error) {
+	normalized := strings.Join(strings.Fields(strings.ToLower(expr)), " ")
+	if t, err := time.ParseInLocation("2006-01-02", normalized, todayMidnight.Location()); err == nil {
+		return t, true, nil
+	}
+	if t, err := time.Parse(time.RFC3339, strings.TrimSpace(expr)); err == nil {
+		return t, false, nil
+	}
+	switch normalized {
+	case "today":
+		return todayMidnight, true, nil
+	case "yesterday":
+		return todayMidnight.AddDate(0, 0, -1), true, nil
+	}
+	if weekday, ok := parseWeekdayPrefix(strings.TrimPrefix(normalized, "last ")); ok {
+		days := (int(todayMidnight.Weekday()) - int(weekday) + 7) % 7
+		if days == 0 {
+			days = 7
+		}
+		return todayMidnight.AddDate(0, 0, -days), true, nil
+	}
+	if matches := lastPattern.FindStringSubmatch(normalized); matches != nil {
+		return subtract(todayMidnight, 1, matches[1]), true, nil
+	}
+	if matches := agoPattern.FindStringSubmatch(normalized); matches != nil {
+		n, err := strconv.Atoi(matches[1])
+		if err != nil { // "a", "an" or "one"
+			n = 1
+		}
+		return subtract(todayMidnight, n, matches[2]), true, nil
+	}
+	return time.Time{}, false, xerrors.Errorf(
+		"unrecognized date %q, use YYYY-MM-DD, RFC3339 or e.g. \"yesterday\", \"last friday\" or \"2 weeks ago\"", expr)
+}
+
+func subtract(t time.Time, n int, unit string) time.Time {
+	switch unit {
+	case "week":
+		return t.AddDate(0, 0, -7*n)
+	case "month":
+		return t.AddDate(0, -n, 0)
+	case "year":
+		return t.AddDate(-n, 0, 0)
+	default:
+		return t.AddDate(0, 0, -n)
+	}
+}
+
+// Ways of reporting tasks completed today.
+const (
+	TodayOff      = "off"      // Tasks completed today aren't reported.
+	TodayMerge    = "merge"    // Tasks completed today are reported with the other completed tasks.
+	TodaySeparate = "separate" // Tasks completed today are reported in their own section.
+)
+
+/*
+TodayModes returns the ways tasks completed today can be reported.
+*/
+func TodayModes() []string {
+	return []string{TodayOff, TodayMerge, TodaySeparate}
+}
+
+/*
+SetToday sets how tasks completed today (i.e. since the end of a window ending at midnight) are reported.  Tasks are
+included until the cutoff time of day (e.g. "09:30"), or until now if the cutoff is empty.  Windows which don't end
+today are left unchanged, since they are reports of the past.
+*/
+func (c *Configuration) SetToday(mode, cutoff string, now time.Time) error {
+	c.TodayEnd, c.MergeToday = time.Time{}, false
+	if mode == "" || mode == TodayOff {
+		return nil
+	}
+	if mode != TodayMerge && mode != TodaySeparate {
+		return xerrors.Errorf("invalid today mode %q, use one of %s", mode, strings.Join(TodayModes(), ", "))
+	}
+	end := now.In(c.TodayMidnight.Location())
+	if cutoff != "" {
+		t, err := time.Parse("15:04", strings.TrimSpace(cutoff))
+		if err != nil {
+			return xerrors.Errorf("invalid today cutoff %q, use HH:MM", cutoff)
+		}
+		today := c.TodayMidnight
+		end = time.Date(today.Year(), today.Month(), today.Day(), t.Hour(), t.Minute(), 0, 0, today.Location())
+	}
+	if !c.LatestDate.Equal(c.TodayMidnight) || !end.After(c.TodayMidnight) {
+		return nil
+	}
+	c.TodayEnd, c.MergeToday = end, mode == TodayMerge
+	return nil
+}
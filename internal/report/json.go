@@ -0,0 +1,138 @@
+package report
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"github.com/jeremy-miller/standup-reporter/internal/source"
+)
+
+/*
+JSONVersion is the version of the JSON output schema.  It is incremented whenever a field is removed or its meaning
+changes; new fields may be added without changing the version.
+*/
+const JSONVersion = 1
+
+/*
+JSON renders a report as a single JSON document, whose schema is documented in the README.  Items of all workspaces are
+merged into a single list per section kind, and each item carries its workspace instead.  Items aren't grouped, since
+each item carries the fields it could be grouped by.
+*/
+type JSON struct{}
+
+type jsonReport struct {
+	Version   int         `json:"version"`
+	Window    jsonWindow  `json:"window"`
+	Completed []jsonItem  `json:"completed"`
+	Today     []jsonItem  `json:"today"`
+	Activity  []jsonItem  `json:"activity"`
+	Planned   []jsonItem  `json:"planned"`
+	Blockers  []jsonItem  `json:"blockers"`
+	Errors    []jsonError `json:"errors"`
+}
+
+type jsonWindow struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+type jsonItem struct {
+	Source       string     `json:"source"`
+	Workspace    string     `json:"workspace,omitempty"`
+	Project      string     `json:"project,omitempty"`
+	Section      string     `json:"section,omitempty"`
+	Tags         []string   `json:"tags,omitempty"`
+	Name         string     `json:"name"`
+	URL          string     `json:"url,omitempty"`
+	CreatedAt    *time.Time `json:"created_at,omitempty"`
+	Due          *time.Time `json:"due,omitempty"`
+	CompletedAt  *time.Time `json:"completed_at,omitempty"`
+	BlockedBy    []string   `json:"blocked_by,omitempty"`
+	Subtasks     []jsonItem `json:"subtasks,omitempty"`
+	SubtaskCount int        `json:"subtask_count,omitempty"`
+	SubtasksDone int        `json:"subtasks_done,omitempty"`
+}
+
+type jsonError struct {
+	Source  string `json:"source,omitempty"`
+	Message string `json:"message"`
+}
+
+/*
+Render writes the report as indented JSON.
+*/
+func (JSON) Render(w io.Writer, report *Report) error {
+	out := jsonReport{
+		Version:   JSONVersion,
+		Window:    jsonWindow{Start: report.Start, End: report.End},
+		Completed: []jsonItem{},
+		Today:     []jsonItem{},
+		Activity:  []jsonItem{},
+		Planned:   []jsonItem{},
+		Blockers:  []jsonItem{},
+		Errors:    []jsonError{},
+	}
+	for _, section := range report.Sections {
+		switch section.Kind {
+		case Completed:
+			out.Completed = appendJSONItems(out.Completed, section.Items)
+		case Today:
+			out.Today = appendJSONItems(out.Today, section.Items)
+		case Activity:
+			out.Activity = appendJSONItems(out.Activity, section.Items)
+		case Planned:
+			out.Planned = appendJSONItems(out.Planned, section.Items)
+		case Blockers:
+			out.Blockers = appendJSONItems(out.Blockers, section.Items)
+		}
+	}
+	for _, err := range report.Errors {
+		out.Errors = append(out.Errors, newJSONError(err))
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(out)
+}
+
+func appendJSONItems(jsonItems []jsonItem, items []source.Item) []jsonItem {
+	for _, item := range items {
+		jsonItems = append(jsonItems, jsonItem{
+			Source:       item.Source,
+			Workspace:    item.Workspace,
+			Project:      item.Project,
+			Section:      item.Section,
+			Tags:         item.Tags,
+			Name:         item.Name,
+			URL:          item.URL,
+			CreatedAt:    optionalTime(item.CreatedAt),
+			Due:          optionalTime(item.Due),
+			CompletedAt:  optionalTime(item.CompletedAt),
+			BlockedBy:    item.BlockedBy,
+			Subtasks:     appendJSONItems(nil, item.Subtasks),
+			SubtaskCount: item.SubtaskCount,
+			SubtasksDone: item.SubtasksDone,
+		})
+	}
+	return jsonItems
+}
+
+/*
+optionalTime returns a pointer to the time, or nil if it is the zero time, so it is left out of the JSON document.
+*/
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+func newJSONError(err error) jsonError {
+	var sourceErr *source.Error
+	if xerrors.As(err, &sourceErr) {
+		return jsonError{Source: sourceErr.Source, Message: sourceErr.Err.Error()}
+	}
+	return jsonError{Message: err.Error()}
+}
@@ -0,0 +1,111 @@
+package report
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jeremy-miller/standup-reporter/internal/source"
+)
+
+//nolint:gochecknoglobals
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"`", "\\`",
+	"*", `\*`,
+	"_", `\_`,
+	"[", `\[`,
+	"]", `\]`,
+	"<", `\<`,
+	">", `\>`,
+)
+
+/*
+Markdown renders a report as Markdown, with each section as a headed bullet list.  Items are linked to the source's web
+application when their URL is known, and are followed by their project name when it is known.  Items of grouped
+sections are nested under the title of their group.
+*/
+type Markdown struct{}
+
+/*
+Render writes the report as Markdown.  Errors are written as a block quote before the sections.
+*/
+func (Markdown) Render(w io.Writer, report *Report) error {
+	bw := bufio.NewWriter(w)
+	for _, err := range report.Errors {
+		for _, line := range strings.Split(err.Error(), "\n") {
+			fmt.Fprintf(bw, "> %s\n", markdownEscaper.Replace(line))
+		}
+		fmt.Fprintln(bw)
+	}
+	workspace := ""
+	for _, section := range report.Sections {
+		level := "##"
+		if section.Workspace != "" {
+			level = "###"
+		}
+		if section.Workspace != workspace {
+			workspace = section.Workspace
+			fmt.Fprintf(bw, "## %s\n\n", markdownEscaper.Replace(workspace))
+		}
+		fmt.Fprintf(bw, "%s %s\n\n", level, section.Title)
+		if len(section.Items) == 0 {
+			fmt.Fprint(bw, "_None_\n\n")
+			continue
+		}
+		writeMarkdownItems(bw, section, report.RollUp)
+		fmt.Fprintln(bw)
+	}
+	return bw.Flush()
+}
+
+/*
+writeMarkdownItems writes the items of a section as a bullet list, nested under the titles of their groups if the
+section is grouped.
+*/
+func writeMarkdownItems(w io.Writer, section Section, rollUp bool) {
+	if len(section.Groups) == 0 {
+		for _, item := range section.Items {
+			writeMarkdownItem(w, "", item, section.Kind, rollUp)
+		}
+		return
+	}
+	for _, group := range section.Groups {
+		fmt.Fprintf(w, "- **%s**\n", markdownEscaper.Replace(groupTitle(group)))
+		for _, item := range group.Items {
+			writeMarkdownItem(w, "  ", item, section.Kind, rollUp)
+		}
+	}
+}
+
+/*
+writeMarkdownItem writes an item as a list entry with the given indentation, followed by its subtasks as a nested list
+unless they are rolled up.
+*/
+func writeMarkdownItem(w io.Writer, indent string, item source.Item, kind Kind, rollUp bool) {
+	fmt.Fprintf(w, "%s- %s\n", indent, markdownItem(item, kind, rollUp))
+	if rollUp {
+		return
+	}
+	for _, subtask := range item.Subtasks {
+		fmt.Fprintf(w, "%s  - %s\n", indent, markdownItem(subtask, kind, false))
+	}
+}
+
+func markdownItem(item source.Item, kind Kind, rollUp bool) string {
+	text := markdownEscaper.Replace(item.Name)
+	if rollUp && item.SubtaskCount > 0 {
+		text = rolledUpName(text, item, kind)
+	}
+	if item.URL != "" {
+		text = fmt.Sprintf("[%s](%s)", text, item.URL)
+	}
+	if item.Project != "" {
+		text = fmt.Sprintf("%s (%s)", text, markdownEscaper.Replace(item.Project))
+	}
+	if len(item.BlockedBy) > 0 {
+		text = fmt.Sprintf("%s, blocked by %s", text, markdownEscaper.Replace(strings.Join(item.BlockedBy, ", ")))
+	}
+	return text
+}
@@ -0,0 +1,148 @@
+package report
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"github.com/jeremy-miller/standup-reporter/internal/source"
+)
+
+// Sort keys.
+const (
+	SortCompleted = "completed" // Completion time, oldest first.
+	SortDue       = "due"       // Due time, soonest first, followed by items which aren't due.
+	SortCreated   = "created"   // Creation time, oldest first.
+	SortName      = "name"      // Name, alphabetically.
+	SortProject   = "project"   // Project name, alphabetically, followed by items without a project.
+)
+
+// Grouping fields.
+const (
+	GroupNone    = "none"    // Items aren't grouped.
+	GroupProject = "project" // Items are grouped by project.
+	GroupSection = "section" // Items are grouped by the section of their project.
+	GroupTag     = "tag"     // Items are grouped by tag, and items with several tags are in several groups.
+)
+
+/*
+SortKeys returns the keys items can be sorted by.
+*/
+func SortKeys() []string {
+	return []string{SortCompleted, SortDue, SortCreated, SortName, SortProject}
+}
+
+/*
+GroupFields returns the fields items can be grouped by.
+*/
+func GroupFields() []string {
+	return []string{GroupNone, GroupProject, GroupSection, GroupTag}
+}
+
+/*
+Sort sorts the items of each section by the given keys, in order of precedence.  Ties are broken by completion time and
+name for completed items and activity, and by project and name for other items, so the order doesn't depend on the
+order in which the sources returned the items.
+*/
+func (r *Report) Sort(keys []string) error {
+	for i := range r.Sections {
+		fallback := []string{SortProject, SortName}
+		if kind := r.Sections[i].Kind; kind == Completed || kind == Today || kind == Activity {
+			fallback = []string{SortCompleted, SortName}
+		}
+		compare, err := itemComparison(append(append([]string{}, keys...), fallback...))
+		if err != nil {
+			return err
+		}
+		items := r.Sections[i].Items
+		sort.SliceStable(items, func(a, b int) bool {
+			return compare(items[a], items[b]) < 0
+		})
+	}
+	return nil
+}
+
+/*
+Group groups the items of each section by the given field, in order of their first appearance in the section.  Groups
+are kept alongside the items, so the items of a section are available whether or not it is grouped.
+*/
+func (r *Report) Group(field string) error {
+	if field == "" || field == GroupNone {
+		return nil
+	}
+	for i := range r.Sections {
+		groups, err := groupBy(field, r.Sections[i].Items)
+		if err != nil {
+			return err
+		}
+		r.Sections[i].Groups = groups
+	}
+	return nil
+}
+
+/*
+itemComparison returns a function comparing two items by the given keys, which returns a negative number if the first
+item sorts before the second, a positive number if it sorts after it, and zero if they are equal.
+*/
+func itemComparison(keys []string) (func(a, b source.Item) int, error) {
+	var comparisons []func(a, b source.Item) int
+	for _, key := range keys {
+		switch key {
+		case SortCompleted:
+			comparisons = append(comparisons, func(a, b source.Item) int { return compareTimes(a.CompletedAt, b.CompletedAt) })
+		case SortDue:
+			comparisons = append(comparisons, func(a, b source.Item) int { return compareTimes(a.Due, b.Due) })
+		case SortCreated:
+			comparisons = append(comparisons, func(a, b source.Item) int { return compareTimes(a.CreatedAt, b.CreatedAt) })
+		case SortName:
+			comparisons = append(comparisons, func(a, b source.Item) int { return compareNames(a.Name, b.Name) })
+		case SortProject:
+			comparisons = append(comparisons, func(a, b source.Item) int { return compareNames(a.Project, b.Project) })
+		default:
+			return nil, xerrors.Errorf("cannot sort by %q", key)
+		}
+	}
+	return func(a, b source.Item) int {
+		for _, compare := range comparisons {
+			if c := compare(a, b); c != 0 {
+				return c
+			}
+		}
+		return 0
+	}, nil
+}
+
+/*
+compareTimes compares two times, with the zero time (i.e. no time) after all other times.
+*/
+func compareTimes(a, b time.Time) int {
+	switch {
+	case a.Equal(b):
+		return 0
+	case a.IsZero():
+		return 1
+	case b.IsZero():
+		return -1
+	case a.Before(b):
+		return -1
+	default:
+		return 1
+	}
+}
+
+/*
+compareNames compares two names case insensitively, with empty names after all other names.
+*/
+func compareNames(a, b string) int {
+	switch {
+	case a == b:
+		return 0
+	case a == "":
+		return 1
+	case b == "":
+		return -1
+	}
+	return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+}
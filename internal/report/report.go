@@ -0,0 +1,334 @@
+/*
+Package report builds the standup report from the items collected from all sources and renders it.
+
+A report is a list of sections (e.g. completed items, activity and planned items) covering a window of time.  When
+items were collected from more than one workspace, each workspace gets its own set of sections.  The items of each
+section can be sorted and grouped (e.g. by project).  Renderers turn a report into output (e.g. plain text, Markdown
+or JSON), so the same report can be printed in different formats.
+*/
+package report
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"github.com/jeremy-miller/standup-reporter/internal/source"
+)
+
+/*
+Kind identifies the type of items in a section.
+*/
+type Kind string
+
+// Section kinds.
+const (
+	Completed Kind = "completed" // Items completed within the report window.
+	Today     Kind = "today"     // Items completed after the end of the report window, i.e. since midnight.
+	Activity  Kind = "activity"  // Activity other than completing items (e.g. comments) within the report window.
+	Planned   Kind = "planned"   // Incomplete items.
+	Blockers  Kind = "blockers"  // Items blocking progress, if reported by a source.
+)
+
+/*
+Section is a titled list of items of the same kind.
+*/
+type Section struct {
+	Kind      Kind          // Type of items in the section.
+	Title     string        // Heading of the section.
+	Workspace string        // Workspace of the items, if the report covers more than one workspace.
+	Items     []source.Item // Items in the section.
+	Groups    []Group       // Items in the section grouped by a field, if the report is grouped.
+}
+
+/*
+Report is a standup report for the window [Start, End).
+*/
+type Report struct {
+	User     string    // Name of the user the report is for, if known.
+	Start    time.Time // Earliest completion time of the completed items.
+	End      time.Time // Latest completion time (exclusive) of the completed items.
+	Sections []Section // Sections of the report, grouped by workspace.
+	Errors   []error   // Errors from sources which could not be fully retrieved.
+	RollUp   bool      // Whether items are followed by the progress of their subtasks rather than the subtasks.
+}
+
+/*
+Renderer writes a report in a specific format.
+*/
+type Renderer interface {
+	Render(w io.Writer, report *Report) error
+}
+
+// Output formats.
+const (
+	TextFormat     = "text"
+	MarkdownFormat = "markdown"
+	JSONFormat     = "json"
+)
+
+/*
+Formats returns the names of the supported output formats.
+*/
+func Formats() []string {
+	return []string{TextFormat, MarkdownFormat, JSONFormat}
+}
+
+/*
+NewRenderer returns the renderer for the given output format.
+*/
+func NewRenderer(format string) (Renderer, error) {
+	switch format {
+	case TextFormat, "":
+		return Text{}, nil
+	case MarkdownFormat:
+		return Markdown{}, nil
+	case JSONFormat:
+		return JSON{}, nil
+	default:
+		return nil, xerrors.Errorf("unknown format %q", format)
+	}
+}
+
+/*
+New builds a report for the window [start, end) from the result collected from all sources.  The times of the items
+are converted to the timezone of the window.  Items completed at or after the end of the window (i.e. today, when the
+sources were asked for them) are reported in their own section, and so are blocked items instead of being planned.
+Either section is left out when there are no such items, and so is the activity section, which only covers the window.
+*/
+func New(start, end time.Time, result *source.Result) *Report {
+	for _, items := range [][]source.Item{result.Completed, result.Activity, result.Planned} {
+		localizeTimes(items, start.Location())
+	}
+	report := &Report{
+		User:   result.User,
+		Start:  start,
+		End:    end,
+		Errors: result.Errors,
+	}
+	completed, today := splitCompleted(result.Completed, end)
+	activity, _ := splitCompleted(result.Activity, end)
+	planned, blockers := splitBlocked(result.Planned)
+	items := map[Kind][]source.Item{
+		Completed: completed, Today: today, Activity: activity, Planned: planned, Blockers: blockers,
+	}
+	kinds := []Kind{Completed}
+	if len(today) > 0 {
+		kinds = append(kinds, Today)
+	}
+	if len(activity) > 0 {
+		kinds = append(kinds, Activity)
+	}
+	kinds = append(kinds, Planned)
+	if len(blockers) > 0 {
+		kinds = append(kinds, Blockers)
+	}
+	title := completedTitle(start, end)
+	workspaces := workspaceNames(result)
+	if len(workspaces) <= 1 {
+		report.Sections = sections(kinds, title, "", items)
+		return report
+	}
+	for _, workspace := range workspaces {
+		filteredItems := make(map[Kind][]source.Item)
+		for kind := range items {
+			filteredItems[kind] = workspaceItems(items[kind], workspace)
+		}
+		report.Sections = append(report.Sections, sections(kinds, title, workspace, filteredItems)...)
+	}
+	return report
+}
+
+/*
+Empty reports whether the report contains no items.
+*/
+func (r *Report) Empty() bool {
+	for _, section := range r.Sections {
+		if len(section.Items) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+/*
+Items returns the items of all sections of the given kind, across all workspaces.
+*/
+func (r *Report) Items(kind Kind) []source.Item {
+	var items []source.Item
+	for _, section := range r.Sections {
+		if section.Kind == kind {
+			items = append(items, section.Items...)
+		}
+	}
+	return items
+}
+
+/*
+Completed returns the items completed within the report window, across all workspaces.
+*/
+func (r *Report) Completed() []source.Item {
+	return r.Items(Completed)
+}
+
+/*
+CompletedToday returns the items completed after the end of the report window, across all workspaces.
+*/
+func (r *Report) CompletedToday() []source.Item {
+	return r.Items(Today)
+}
+
+/*
+Activity returns the activity other than completing items within the report window, across all workspaces.
+*/
+func (r *Report) Activity() []source.Item {
+	return r.Items(Activity)
+}
+
+/*
+Planned returns the incomplete items, across all workspaces.
+*/
+func (r *Report) Planned() []source.Item {
+	return r.Items(Planned)
+}
+
+/*
+Blockers returns the items blocking progress, across all workspaces.
+*/
+func (r *Report) Blockers() []source.Item {
+	return r.Items(Blockers)
+}
+
+/*
+completedTitle returns the title of the completed section, which names the window unless it ends today (i.e. it is the
+usual window of the previous working day).
+*/
+func completedTitle(start, end time.Time) string {
+	now := time.Now().In(end.Location())
+	if end.Equal(time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, end.Location())) {
+		return "Yesterday's Activity"
+	}
+	const layout = "Mon Jan 2"
+	last := end.Add(-time.Nanosecond)
+	if last.YearDay() == start.YearDay() && last.Year() == start.Year() {
+		return fmt.Sprintf("Activity on %s", start.Format(layout))
+	}
+	return fmt.Sprintf("Activity from %s to %s", start.Format(layout), last.Format(layout))
+}
+
+/*
+groupTitle returns the title of a group, which is its key unless the items of the group have no value for the field.
+*/
+func groupTitle(group Group) string {
+	if group.Key == "" {
+		return "Other"
+	}
+	return group.Key
+}
+
+func sections(kinds []Kind, completedTitle, workspace string, items map[Kind][]source.Item) []Section {
+	var result []Section
+	for _, kind := range kinds {
+		title := completedTitle
+		switch kind {
+		case Today:
+			title = "Done Since Midnight"
+		case Activity:
+			title = "Comments and Updates"
+		case Planned:
+			title = "Today's Planned Activity"
+		case Blockers:
+			title = "Blockers"
+		}
+		result = append(result, Section{Kind: kind, Title: title, Workspace: workspace, Items: items[kind]})
+	}
+	return result
+}
+
+/*
+localizeTimes converts the times of the items and of their subtasks to the given location.  Times which aren't set are
+left as they are.
+*/
+func localizeTimes(items []source.Item, location *time.Location) {
+	in := func(t time.Time) time.Time {
+		if t.IsZero() {
+			return t
+		}
+		return t.In(location)
+	}
+	for i := range items {
+		items[i].CreatedAt = in(items[i].CreatedAt)
+		items[i].Due = in(items[i].Due)
+		items[i].CompletedAt = in(items[i].CompletedAt)
+		localizeTimes(items[i].Subtasks, location)
+	}
+}
+
+/*
+splitBlocked splits the planned items into those which aren't blocked and those which are.
+*/
+func splitBlocked(items []source.Item) ([]source.Item, []source.Item) {
+	var planned, blocked []source.Item
+	for i, item := range items {
+		if item.Blocked {
+			blocked = append(blocked, items[i])
+		} else {
+			planned = append(planned, items[i])
+		}
+	}
+	return planned, blocked
+}
+
+/*
+splitCompleted splits the completed items into those completed before the end of the window and those completed after.
+*/
+func splitCompleted(items []source.Item, end time.Time) ([]source.Item, []source.Item) {
+	var before, after []source.Item
+	for i, item := range items {
+		if item.CompletedAt.Before(end) {
+			before = append(before, items[i])
+		} else {
+			after = append(after, items[i])
+		}
+	}
+	return before, after
+}
+
+func workspaceNames(result *source.Result) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, items := range [][]source.Item{result.Completed, result.Activity, result.Planned} {
+		for _, item := range items {
+			if !seen[item.Workspace] {
+				seen[item.Workspace] = true
+				names = append(names, item.Workspace)
+			}
+		}
+	}
+	return names
+}
+
+func workspaceItems(items []source.Item, workspace string) []source.Item {
+	var filteredItems []source.Item
+	for i, item := range items {
+		if item.Workspace == workspace {
+			filteredItems = append(filteredItems, items[i])
+		}
+	}
+	return filteredItems
+}
+
+/*
+rolledUpName returns the name of an item followed by the progress of its subtasks (e.g. "Task (3/5 subtasks done)").
+Completed items which are only reported because some of their subtasks were completed are named after that progress
+instead (e.g. "3/5 subtasks done on Task").
+*/
+func rolledUpName(name string, item source.Item, kind Kind) string {
+	progress := fmt.Sprintf("%d/%d subtasks done", item.SubtasksDone, item.SubtaskCount)
+	if item.CompletedAt.IsZero() && (kind == Completed || kind == Today) {
+		return fmt.Sprintf("%s on %s", progress, name)
+	}
+	return fmt.Sprintf("%s (%s)", name, progress)
+}
@@ -0,0 +1,156 @@
+package report
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jeremy-miller/standup-reporter/internal/source"
+)
+
+func TestGroupBy(t *testing.T) {
+	items := []source.Item{
+		{Source: "asana", Workspace: "Workspace 1", Project: "Project 1", Section: "Doing", Tags: []string{"a", "b"}, Name: "Task 1"}, //nolint:lll
+		{Source: "asana", Workspace: "Workspace 2", Project: "Project 2", Name: "Task 2"},
+		{Source: "asana", Workspace: "Workspace 1", Project: "Project 1", Section: "Doing", Tags: []string{"b"}, Name: "Task 3"}, //nolint:lll
+	}
+	testCases := []struct {
+		name     string
+		field    string
+		expected []Group
+	}{
+		{
+			name:     "Source",
+			field:    "source",
+			expected: []Group{{Key: "asana", Items: items}},
+		},
+		{
+			name:  "Workspace",
+			field: "workspace",
+			expected: []Group{
+				{Key: "Workspace 1", Items: []source.Item{items[0], items[2]}},
+				{Key: "Workspace 2", Items: []source.Item{items[1]}},
+			},
+		},
+		{
+			name:  "Project",
+			field: "project",
+			expected: []Group{
+				{Key: "Project 1", Items: []source.Item{items[0], items[2]}},
+				{Key: "Project 2", Items: []source.Item{items[1]}},
+			},
+		},
+		{
+			name:  "Section",
+			field: "section",
+			expected: []Group{
+				{Key: "Doing", Items: []source.Item{items[0], items[2]}},
+				{Key: "", Items: []source.Item{items[1]}},
+			},
+		},
+		{
+			name:  "Tag",
+			field: "tag",
+			expected: []Group{
+				{Key: "a", Items: []source.Item{items[0]}},
+				{Key: "b", Items: []source.Item{items[0], items[2]}},
+				{Key: "", Items: []source.Item{items[1]}},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := groupBy(tc.field, items)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestGroupByUnknownField(t *testing.T) {
+	_, err := groupBy("assignee", nil)
+	assert.EqualError(t, err, `cannot group by "assignee"`)
+}
+
+func TestTruncate(t *testing.T) {
+	testCases := []struct {
+		name     string
+		length   int
+		text     string
+		expected string
+	}{
+		{name: "Shorter", length: 10, text: "Task 1", expected: "Task 1"},
+		{name: "Equal", length: 6, text: "Task 1", expected: "Task 1"},
+		{name: "Longer", length: 5, text: "Task 1", expected: "Task…"},
+		{name: "Multibyte", length: 3, text: "Tâsk 1", expected: "Tâ…"},
+		{name: "Zero", length: 0, text: "Task 1", expected: ""},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, truncate(tc.length, tc.text))
+		})
+	}
+}
+
+func TestFormatDate(t *testing.T) {
+	date := time.Date(2019, time.June, 3, 12, 0, 0, 0, time.UTC)
+	assert.Equal(t, "Mon Jun 3", formatDate("Mon Jan 2", date))
+}
+
+func TestCompletedTitle(t *testing.T) {
+	now := time.Now().Local()
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	start := time.Date(2019, time.May, 20, 0, 0, 0, 0, time.Local)
+	testCases := []struct {
+		name     string
+		start    time.Time
+		end      time.Time
+		expected string
+	}{
+		{name: "EndsToday", start: midnight.AddDate(0, 0, -3), end: midnight, expected: "Yesterday's Activity"},
+		{name: "OneDay", start: start, end: start.AddDate(0, 0, 1), expected: "Activity on Mon May 20"},
+		{name: "Days", start: start, end: start.AddDate(0, 0, 12), expected: "Activity from Mon May 20 to Fri May 31"},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, completedTitle(tc.start, tc.end))
+		})
+	}
+}
+
+func TestItemComparison(t *testing.T) {
+	early := time.Date(2019, time.June, 3, 9, 0, 0, 0, time.UTC)
+	late := early.Add(time.Hour)
+	testCases := []struct {
+		name     string
+		keys     []string
+		a        source.Item
+		b        source.Item
+		expected int
+	}{
+		{name: "Completed", keys: []string{SortCompleted}, a: source.Item{CompletedAt: early}, b: source.Item{CompletedAt: late}, expected: -1}, //nolint:lll
+		{name: "Due", keys: []string{SortDue}, a: source.Item{Due: late}, b: source.Item{Due: early}, expected: 1},
+		{name: "NotDue", keys: []string{SortDue}, a: source.Item{}, b: source.Item{Due: early}, expected: 1},
+		{name: "Created", keys: []string{SortCreated}, a: source.Item{CreatedAt: early}, b: source.Item{CreatedAt: early}, expected: 0}, //nolint:lll
+		{name: "Name", keys: []string{SortName}, a: source.Item{Name: "apple"}, b: source.Item{Name: "Banana"}, expected: -1},
+		{name: "NoProject", keys: []string{SortProject}, a: source.Item{}, b: source.Item{Project: "Project 1"}, expected: 1},
+		{name: "TieBreaker", keys: []string{SortProject, SortName}, a: source.Item{Name: "Task 2"}, b: source.Item{Name: "Task 1"}, expected: 1}, //nolint:lll
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			compare, err := itemComparison(tc.keys)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expected, compare(tc.a, tc.b))
+		})
+	}
+}
+
+func TestItemComparisonUnknownKey(t *testing.T) {
+	_, err := itemComparison([]string{"priority"})
+	assert.EqualError(t, err, `cannot sort by "priority"`)
+}
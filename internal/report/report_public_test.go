@@ -0,0 +1,641 @@
+package report_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/xerrors"
+
+	"github.com/jeremy-miller/standup-reporter/internal/report"
+	"github.com/jeremy-miller/standup-reporter/internal/source"
+)
+
+func window() (time.Time, time.Time) {
+	now := time.Now().Local()
+	end := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	return end.AddDate(0, 0, -1), end
+}
+
+func TestNewSingleWorkspace(t *testing.T) {
+	assert := assert.New(t)
+	start, end := window()
+	completedAt := start.Add(12 * time.Hour)
+	result := &source.Result{
+		Completed: []source.Item{{Source: "asana", Name: "Task 1", CompletedAt: completedAt}},
+		Planned:   []source.Item{{Source: "asana", Name: "Task 2"}},
+		Errors:    []error{xerrors.New("partial failure")},
+	}
+	standup := report.New(start, end, result)
+	expected := &report.Report{
+		Start: start,
+		End:   end,
+		Sections: []report.Section{
+			{
+				Kind:  report.Completed,
+				Title: "Yesterday's Activity",
+				Items: []source.Item{{Source: "asana", Name: "Task 1", CompletedAt: completedAt}},
+			},
+			{
+				Kind:  report.Planned,
+				Title: "Today's Planned Activity",
+				Items: []source.Item{{Source: "asana", Name: "Task 2"}},
+			},
+		},
+		Errors: result.Errors,
+	}
+	assert.Equal(expected, standup)
+	assert.False(standup.Empty())
+}
+
+func TestNewMultipleWorkspaces(t *testing.T) {
+	assert := assert.New(t)
+	start, end := window()
+	result := &source.Result{
+		Planned: []source.Item{
+			{Workspace: "Workspace 1", Name: "Task 1"},
+			{Workspace: "Workspace 2", Name: "Task 2"},
+		},
+	}
+	standup := report.New(start, end, result)
+	assert.Len(standup.Sections, 4)
+	assert.Equal("Workspace 1", standup.Sections[0].Workspace)
+	assert.Empty(standup.Sections[0].Items)
+	assert.Equal([]source.Item{{Workspace: "Workspace 1", Name: "Task 1"}}, standup.Sections[1].Items)
+	assert.Equal("Workspace 2", standup.Sections[3].Workspace)
+	assert.Equal([]source.Item{{Workspace: "Workspace 2", Name: "Task 2"}}, standup.Sections[3].Items)
+}
+
+func TestNewCompletedToday(t *testing.T) {
+	assert := assert.New(t)
+	start, end := window()
+	result := &source.Result{
+		Completed: []source.Item{
+			{Name: "Task 1", CompletedAt: start.Add(12 * time.Hour)},
+			{Name: "Task 2", CompletedAt: end.Add(time.Hour)},
+		},
+	}
+	standup := report.New(start, end, result)
+	assert.Len(standup.Sections, 3)
+	assert.Equal(report.Today, standup.Sections[1].Kind)
+	assert.Equal("Done Since Midnight", standup.Sections[1].Title)
+	assert.Equal([]source.Item{{Name: "Task 1", CompletedAt: start.Add(12 * time.Hour)}}, standup.Completed())
+	assert.Equal([]source.Item{{Name: "Task 2", CompletedAt: end.Add(time.Hour)}}, standup.CompletedToday())
+}
+
+func TestNewBlockers(t *testing.T) {
+	assert := assert.New(t)
+	start, end := window()
+	result := &source.Result{
+		Planned: []source.Item{{Name: "Task 1"}, {Name: "Task 2", Blocked: true}},
+	}
+	standup := report.New(start, end, result)
+	assert.Len(standup.Sections, 3)
+	assert.Equal(report.Blockers, standup.Sections[2].Kind)
+	assert.Equal("Blockers", standup.Sections[2].Title)
+	assert.Equal([]source.Item{{Name: "Task 1"}}, standup.Planned())
+	assert.Equal([]source.Item{{Name: "Task 2", Blocked: true}}, standup.Blockers())
+}
+
+func TestNewActivity(t *testing.T) {
+	assert := assert.New(t)
+	start, end := window()
+	result := &source.Result{
+		Activity: []source.Item{
+			{Name: "Commented on Task 1", CompletedAt: start.Add(time.Hour)},
+			{Name: "Commented on Task 2", CompletedAt: end.Add(time.Hour)},
+		},
+		Planned: []source.Item{{Name: "Task 3"}},
+	}
+	standup := report.New(start, end, result)
+	assert.Len(standup.Sections, 3)
+	assert.Equal(report.Activity, standup.Sections[1].Kind)
+	assert.Equal("Comments and Updates", standup.Sections[1].Title)
+	assert.Equal([]source.Item{{Name: "Commented on Task 1", CompletedAt: start.Add(time.Hour)}}, standup.Activity())
+}
+
+func TestEmpty(t *testing.T) {
+	start, end := window()
+	standup := report.New(start, end, &source.Result{Errors: []error{xerrors.New("failure")}})
+	assert.True(t, standup.Empty())
+}
+
+func TestTextRender(t *testing.T) {
+	start, end := window()
+	result := &source.Result{
+		Completed: []source.Item{{Name: "Task 1", CompletedAt: start.Add(time.Hour)}},
+		Planned:   []source.Item{{Name: "Task 2"}, {Name: "Task 3"}},
+		Errors:    []error{xerrors.New("partial failure")},
+	}
+	var buf bytes.Buffer
+	err := report.Text{}.Render(&buf, report.New(start, end, result))
+	assert.Nil(t, err)
+	expected := "\npartial failure\n" +
+		"\nYesterday's Activity:\n- Task 1\n" +
+		"\nToday's Planned Activity:\n- Task 2\n- Task 3\n\n"
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestTextRenderMultipleWorkspaces(t *testing.T) {
+	start, end := window()
+	result := &source.Result{
+		Planned: []source.Item{
+			{Workspace: "Workspace 1", Name: "Task 1"},
+			{Workspace: "Workspace 2", Name: "Task 2"},
+		},
+	}
+	var buf bytes.Buffer
+	err := report.Text{}.Render(&buf, report.New(start, end, result))
+	assert.Nil(t, err)
+	expected := "\nWorkspace: Workspace 1\n" +
+		"\nYesterday's Activity:\n" +
+		"\nToday's Planned Activity:\n- Task 1\n" +
+		"\nWorkspace: Workspace 2\n" +
+		"\nYesterday's Activity:\n" +
+		"\nToday's Planned Activity:\n- Task 2\n\n"
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestNewRenderer(t *testing.T) {
+	testCases := []struct {
+		name     string
+		format   string
+		expected report.Renderer
+	}{
+		{name: "Default", format: "", expected: report.Text{}},
+		{name: "Text", format: report.TextFormat, expected: report.Text{}},
+		{name: "Markdown", format: report.MarkdownFormat, expected: report.Markdown{}},
+		{name: "JSON", format: report.JSONFormat, expected: report.JSON{}},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			renderer, err := report.NewRenderer(tc.format)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expected, renderer)
+		})
+	}
+}
+
+func TestNewRendererUnknown(t *testing.T) {
+	_, err := report.NewRenderer("html")
+	assert.EqualError(t, err, `unknown format "html"`)
+}
+
+func TestSort(t *testing.T) {
+	start, end := window()
+	result := &source.Result{
+		Completed: []source.Item{
+			{Name: "Task 2", CompletedAt: start.Add(time.Hour)},
+			{Name: "Task 1", CompletedAt: start.Add(2 * time.Hour)},
+		},
+		Planned: []source.Item{
+			{Project: "Project 2", Name: "Task 3"},
+			{Project: "Project 1", Name: "Task 5", Due: end},
+			{Project: "Project 1", Name: "Task 4"},
+		},
+	}
+	standup := report.New(start, end, result)
+	assert.Nil(t, standup.Sort(nil))
+	assert.Equal(t, []string{"Task 2", "Task 1"}, names(standup.Completed()))
+	assert.Equal(t, []string{"Task 4", "Task 5", "Task 3"}, names(standup.Planned()))
+	assert.Nil(t, standup.Sort([]string{report.SortDue, report.SortName}))
+	assert.Equal(t, []string{"Task 1", "Task 2"}, names(standup.Completed()))
+	assert.Equal(t, []string{"Task 5", "Task 3", "Task 4"}, names(standup.Planned()))
+}
+
+func TestGroup(t *testing.T) {
+	start, end := window()
+	result := &source.Result{
+		Planned: []source.Item{
+			{Project: "Project 1", Name: "Task 1"},
+			{Name: "Task 2"},
+			{Project: "Project 1", Name: "Task 3"},
+		},
+	}
+	standup := report.New(start, end, result)
+	assert.Nil(t, standup.Group(report.GroupNone))
+	assert.Nil(t, standup.Sections[1].Groups)
+	assert.Nil(t, standup.Group(report.GroupProject))
+	assert.Empty(t, standup.Sections[0].Groups)
+	expected := []report.Group{
+		{Key: "Project 1", Items: []source.Item{result.Planned[0], result.Planned[2]}},
+		{Key: "", Items: []source.Item{result.Planned[1]}},
+	}
+	assert.Equal(t, expected, standup.Sections[1].Groups)
+	assert.Error(t, standup.Group("assignee"))
+}
+
+func TestTextRenderBlockers(t *testing.T) {
+	start, end := window()
+	result := &source.Result{
+		Planned: []source.Item{{Name: "Task 1", Blocked: true, BlockedBy: []string{"Task 2", "Task 3"}}},
+	}
+	var buf bytes.Buffer
+	err := report.Text{}.Render(&buf, report.New(start, end, result))
+	assert.Nil(t, err)
+	expected := "\nYesterday's Activity:\n" +
+		"\nToday's Planned Activity:\n" +
+		"\nBlockers:\n- Task 1 (blocked by Task 2, Task 3)\n\n"
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestMarkdownRenderBlockers(t *testing.T) {
+	start, end := window()
+	result := &source.Result{
+		Planned: []source.Item{{Name: "Task 1", Project: "Project 1", Blocked: true, BlockedBy: []string{"Task_2"}}},
+	}
+	var buf bytes.Buffer
+	err := report.Markdown{}.Render(&buf, report.New(start, end, result))
+	assert.Nil(t, err)
+	expected := "## Yesterday's Activity\n\n_None_\n\n" +
+		"## Today's Planned Activity\n\n_None_\n\n" +
+		"## Blockers\n\n- Task 1 (Project 1), blocked by Task\\_2\n\n"
+	assert.Equal(t, expected, buf.String())
+}
+
+func subtaskResult(end time.Time) *source.Result {
+	completedAt := end.Add(-time.Hour)
+	return &source.Result{
+		Completed: []source.Item{{
+			Name:         "Task 1",
+			Subtasks:     []source.Item{{Name: "Subtask 1", CompletedAt: completedAt}},
+			SubtaskCount: 3,
+			SubtasksDone: 2,
+		}},
+		Planned: []source.Item{{
+			Name:         "Task 2",
+			Subtasks:     []source.Item{{Name: "Subtask 2"}},
+			SubtaskCount: 2,
+			SubtasksDone: 1,
+		}},
+	}
+}
+
+func TestTextRenderSubtasks(t *testing.T) {
+	start, end := window()
+	var buf bytes.Buffer
+	err := report.Text{}.Render(&buf, report.New(start, end, subtaskResult(end)))
+	assert.Nil(t, err)
+	expected := "\nYesterday's Activity:\n- Task 1\n  - Subtask 1\n" +
+		"\nToday's Planned Activity:\n- Task 2\n  - Subtask 2\n\n"
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestTextRenderSubtasksRolledUp(t *testing.T) {
+	start, end := window()
+	standup := report.New(start, end, subtaskResult(end))
+	standup.RollUp = true
+	var buf bytes.Buffer
+	err := report.Text{}.Render(&buf, standup)
+	assert.Nil(t, err)
+	expected := "\nYesterday's Activity:\n- 2/3 subtasks done on Task 1\n" +
+		"\nToday's Planned Activity:\n- Task 2 (1/2 subtasks done)\n\n"
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestMarkdownRenderSubtasks(t *testing.T) {
+	start, end := window()
+	var buf bytes.Buffer
+	err := report.Markdown{}.Render(&buf, report.New(start, end, subtaskResult(end)))
+	assert.Nil(t, err)
+	expected := "## Yesterday's Activity\n\n- Task 1\n  - Subtask 1\n\n" +
+		"## Today's Planned Activity\n\n- Task 2\n  - Subtask 2\n\n"
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestMarkdownRenderSubtasksRolledUp(t *testing.T) {
+	start, end := window()
+	result := subtaskResult(end)
+	result.Completed[0].URL = "https://app.asana.com/0/1/2"
+	standup := report.New(start, end, result)
+	standup.RollUp = true
+	var buf bytes.Buffer
+	err := report.Markdown{}.Render(&buf, standup)
+	assert.Nil(t, err)
+	expected := "## Yesterday's Activity\n\n- [2/3 subtasks done on Task 1](https://app.asana.com/0/1/2)\n\n" +
+		"## Today's Planned Activity\n\n- Task 2 (1/2 subtasks done)\n\n"
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestTextRenderActivity(t *testing.T) {
+	start, end := window()
+	result := &source.Result{
+		Activity: []source.Item{{Name: "Commented on Task 1", CompletedAt: start.Add(time.Hour)}},
+	}
+	var buf bytes.Buffer
+	err := report.Text{}.Render(&buf, report.New(start, end, result))
+	assert.Nil(t, err)
+	expected := "\nYesterday's Activity:\n" +
+		"\nComments and Updates:\n- Commented on Task 1\n" +
+		"\nToday's Planned Activity:\n\n"
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestTextRenderGrouped(t *testing.T) {
+	start, end := window()
+	result := &source.Result{
+		Planned: []source.Item{{Section: "Doing", Name: "Task 1"}, {Name: "Task 2"}},
+	}
+	standup := report.New(start, end, result)
+	assert.Nil(t, standup.Group(report.GroupSection))
+	var buf bytes.Buffer
+	err := report.Text{}.Render(&buf, standup)
+	assert.Nil(t, err)
+	expected := "\nYesterday's Activity:\n" +
+		"\nToday's Planned Activity:\n- Doing\n  - Task 1\n- Other\n  - Task 2\n\n"
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestMarkdownRenderGrouped(t *testing.T) {
+	start, end := window()
+	result := &source.Result{
+		Planned: []source.Item{{Tags: []string{"needs_review"}, Name: "Task 1"}},
+	}
+	standup := report.New(start, end, result)
+	assert.Nil(t, standup.Group(report.GroupTag))
+	var buf bytes.Buffer
+	err := report.Markdown{}.Render(&buf, standup)
+	assert.Nil(t, err)
+	expected := "## Yesterday's Activity\n\n_None_\n\n" +
+		"## Today's Planned Activity\n\n- **needs\\_review**\n  - Task 1\n\n"
+	assert.Equal(t, expected, buf.String())
+}
+
+func names(items []source.Item) []string {
+	var names []string
+	for _, item := range items {
+		names = append(names, item.Name)
+	}
+	return names
+}
+
+func TestMarkdownRender(t *testing.T) {
+	start, end := window()
+	result := &source.Result{
+		Completed: []source.Item{
+			{Project: "Project 1", Name: "Task 1", URL: "https://app.asana.com/0/1/1", CompletedAt: start.Add(time.Hour)},
+		},
+		Planned: []source.Item{
+			{Name: "Task_2 [draft]", URL: "https://app.asana.com/0/1/2"},
+			{Project: "Project 1", Name: "Task 3"},
+		},
+		Errors: []error{xerrors.New("error retrieving tasks:\nproject 2 not found")},
+	}
+	var buf bytes.Buffer
+	err := report.Markdown{}.Render(&buf, report.New(start, end, result))
+	assert.Nil(t, err)
+	expected := "> error retrieving tasks:\n> project 2 not found\n\n" +
+		"## Yesterday's Activity\n\n" +
+		"- [Task 1](https://app.asana.com/0/1/1) (Project 1)\n\n" +
+		"## Today's Planned Activity\n\n" +
+		"- [Task\\_2 \\[draft\\]](https://app.asana.com/0/1/2)\n" +
+		"- Task 3 (Project 1)\n\n"
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestMarkdownRenderMultipleWorkspaces(t *testing.T) {
+	start, end := window()
+	result := &source.Result{
+		Planned: []source.Item{
+			{Workspace: "Workspace 1", Name: "Task 1"},
+			{Workspace: "Workspace 2", Name: "Task 2"},
+		},
+	}
+	var buf bytes.Buffer
+	err := report.Markdown{}.Render(&buf, report.New(start, end, result))
+	assert.Nil(t, err)
+	expected := "## Workspace 1\n\n" +
+		"### Yesterday's Activity\n\n_None_\n\n" +
+		"### Today's Planned Activity\n\n- Task 1\n\n" +
+		"## Workspace 2\n\n" +
+		"### Yesterday's Activity\n\n_None_\n\n" +
+		"### Today's Planned Activity\n\n- Task 2\n\n"
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestJSONRender(t *testing.T) {
+	start := time.Date(2019, time.June, 3, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2019, time.June, 4, 0, 0, 0, 0, time.UTC)
+	result := &source.Result{
+		Completed: []source.Item{
+			{
+				Source:      "asana",
+				Workspace:   "Workspace 1",
+				Project:     "Project 1",
+				Name:        "Task 1",
+				URL:         "https://app.asana.com/0/1/1",
+				CompletedAt: time.Date(2019, time.June, 3, 12, 0, 0, 0, time.UTC),
+			},
+		},
+		Activity: []source.Item{
+			{
+				Source:      "asana",
+				Workspace:   "Workspace 1",
+				Name:        "Commented on Task 5",
+				CompletedAt: time.Date(2019, time.June, 3, 13, 0, 0, 0, time.UTC),
+			},
+		},
+		Planned: []source.Item{
+			{
+				Source:       "asana",
+				Workspace:    "Workspace 2",
+				Name:         "Task 2",
+				Subtasks:     []source.Item{{Source: "asana", Name: "Subtask 1"}},
+				SubtaskCount: 2,
+				SubtasksDone: 1,
+			},
+			{Source: "asana", Workspace: "Workspace 2", Section: "Blocked", Name: "Task 3", Blocked: true, BlockedBy: []string{"Task 4"}}, //nolint:lll
+		},
+		Errors: []error{
+			&source.Error{Source: "asana", Err: xerrors.New("project 2 not found")},
+			xerrors.New("unknown failure"),
+		},
+	}
+	var buf bytes.Buffer
+	err := report.JSON{}.Render(&buf, report.New(start, end, result))
+	assert.Nil(t, err)
+	expected := `{
+  "version": 1,
+  "window": {
+    "start": "2019-06-03T00:00:00Z",
+    "end": "2019-06-04T00:00:00Z"
+  },
+  "completed": [
+    {
+      "source": "asana",
+      "workspace": "Workspace 1",
+      "project": "Project 1",
+      "name": "Task 1",
+      "url": "https://app.asana.com/0/1/1",
+      "completed_at": "2019-06-03T12:00:00Z"
+    }
+  ],
+  "today": [],
+  "activity": [
+    {
+      "source": "asana",
+      "workspace": "Workspace 1",
+      "name": "Commented on Task 5",
+      "completed_at": "2019-06-03T13:00:00Z"
+    }
+  ],
+  "planned": [
+    {
+      "source": "asana",
+      "workspace": "Workspace 2",
+      "name": "Task 2",
+      "subtasks": [
+        {
+          "source": "asana",
+          "name": "Subtask 1"
+        }
+      ],
+      "subtask_count": 2,
+      "subtasks_done": 1
+    }
+  ],
+  "blockers": [
+    {
+      "source": "asana",
+      "workspace": "Workspace 2",
+      "section": "Blocked",
+      "name": "Task 3",
+      "blocked_by": [
+        "Task 4"
+      ]
+    }
+  ],
+  "errors": [
+    {
+      "source": "asana",
+      "message": "project 2 not found"
+    },
+    {
+      "message": "unknown failure"
+    }
+  ]
+}
+`
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestJSONRenderEmpty(t *testing.T) {
+	start := time.Date(2019, time.June, 3, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2019, time.June, 4, 0, 0, 0, 0, time.UTC)
+	var buf bytes.Buffer
+	err := report.JSON{}.Render(&buf, report.New(start, end, &source.Result{}))
+	assert.Nil(t, err)
+	expected := `{
+  "version": 1,
+  "window": {
+    "start": "2019-06-03T00:00:00Z",
+    "end": "2019-06-04T00:00:00Z"
+  },
+  "completed": [],
+  "today": [],
+  "activity": [],
+  "planned": [],
+  "blockers": [],
+  "errors": []
+}
+`
+	assert.Equal(t, expected, buf.String())
+}
+
+func writeTemplate(t *testing.T, text string) string {
+	f, err := ioutil.TempFile("", "standup-*.tmpl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(text); err != nil {
+		t.Fatal(err)
+	}
+	return f.Name()
+}
+
+func TestTemplateRender(t *testing.T) {
+	path := writeTemplate(t, `Standup for {{.User}} ({{date "Jan 2" .Start}} - {{date "Jan 2" .End}})
+{{range groupBy "project" .Completed}}{{.Key}}:
+{{range .Items}}* {{truncate 8 .Name}}
+{{end}}{{end}}Next: {{len .Planned}} task(s)
+`)
+	defer os.Remove(path)
+	start := time.Date(2019, time.June, 3, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2019, time.June, 4, 0, 0, 0, 0, time.UTC)
+	result := &source.Result{
+		User: "User 1",
+		Completed: []source.Item{
+			{Project: "Project 1", Name: "Task 1 with a long name", CompletedAt: start.Add(time.Hour)},
+			{Project: "Project 2", Name: "Task 2", CompletedAt: start.Add(2 * time.Hour)},
+		},
+		Planned: []source.Item{{Name: "Task 3"}},
+	}
+	tmpl, err := report.NewTemplate(path)
+	assert.Nil(t, err)
+	var buf bytes.Buffer
+	err = tmpl.Render(&buf, report.New(start, end, result))
+	assert.Nil(t, err)
+	expected := "Standup for User 1 (Jun 3 - Jun 4)\n" +
+		"Project 1:\n* Task 1 …\n" +
+		"Project 2:\n* Task 2\n" +
+		"Next: 1 task(s)\n"
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestNewTemplateParseError(t *testing.T) {
+	path := writeTemplate(t, "{{range .Completed}")
+	defer os.Remove(path)
+	_, err := report.NewTemplate(path)
+	assert.Error(t, err)
+}
+
+func TestNewTemplateMissingFile(t *testing.T) {
+	_, err := report.NewTemplate("does-not-exist.tmpl")
+	assert.Error(t, err)
+}
+
+func TestTemplateRenderError(t *testing.T) {
+	path := writeTemplate(t, `{{groupBy "assignee" .Completed}}`)
+	defer os.Remove(path)
+	tmpl, err := report.NewTemplate(path)
+	assert.Nil(t, err)
+	start, end := window()
+	err = tmpl.Render(&bytes.Buffer{}, report.New(start, end, &source.Result{}))
+	assert.Error(t, err)
+}
+
+func TestNewConvertsCompletionTimes(t *testing.T) {
+	location, err := time.LoadLocation("Asia/Tokyo")
+	assert.Nil(t, err)
+	start := time.Date(2019, time.June, 3, 0, 0, 0, 0, location)
+	end := start.AddDate(0, 0, 1)
+	completedAt := time.Date(2019, time.June, 3, 3, 0, 0, 0, time.UTC)
+	result := &source.Result{Completed: []source.Item{{Name: "Task 1", CompletedAt: completedAt}}}
+	standup := report.New(start, end, result)
+	actual := standup.Completed()[0].CompletedAt
+	assert.Equal(t, location, actual.Location())
+	assert.True(t, completedAt.Equal(actual))
+}
+
+func TestNewConvertsCreationTimes(t *testing.T) {
+	assert := assert.New(t)
+	location, err := time.LoadLocation("Asia/Tokyo")
+	assert.Nil(err)
+	start := time.Date(2019, time.June, 3, 0, 0, 0, 0, location)
+	end := start.AddDate(0, 0, 1)
+	createdAt := time.Date(2019, time.June, 1, 3, 0, 0, 0, time.UTC)
+	result := &source.Result{
+		Planned: []source.Item{{Name: "Task 1", CreatedAt: createdAt, Subtasks: []source.Item{{CreatedAt: createdAt}}}},
+	}
+	standup := report.New(start, end, result)
+	planned := standup.Planned()[0]
+	assert.Equal(location, planned.CreatedAt.Location())
+	assert.True(createdAt.Equal(planned.CreatedAt))
+	assert.Equal(location, planned.Subtasks[0].CreatedAt.Location())
+	assert.True(planned.CompletedAt.IsZero())
+}
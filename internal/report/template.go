@@ -0,0 +1,133 @@
+package report
+
+import (
+	"io"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/xerrors"
+
+	"github.com/jeremy-miller/standup-reporter/internal/source"
+)
+
+/*
+Template renders a report using a user-defined text/template.  The template is executed with the *Report, so it can
+use its fields (e.g. .User, .Start, .End, .Sections and .Errors) and methods (e.g. .Completed, .CompletedToday,
+.Activity, .Planned and .Blockers), along with the helper functions below.  Items reported with their subtasks have
+.Subtasks, .SubtaskCount and .SubtasksDone.
+
+	date LAYOUT TIME     formats a time using a Go time layout (e.g. "Mon Jan 2")
+	groupBy FIELD ITEMS  groups items by "source", "workspace", "project", "section" or "tag", in order of first appearance
+	truncate N TEXT      shortens text to at most N characters, ending with "…" if it was shortened
+	join SEP LIST        joins a list of strings with a separator
+	lower TEXT           converts text to lower case
+	upper TEXT           converts text to upper case
+*/
+type Template struct {
+	template *template.Template
+}
+
+/*
+Group is a set of items sharing the same value of a field, as returned by the groupBy template function and kept in
+grouped sections.  The key is empty for items without a value (e.g. items without a project).
+*/
+type Group struct {
+	Key   string        // Value of the field shared by the items.
+	Items []source.Item // Items in the group.
+}
+
+/*
+NewTemplate parses the template file at the given path.
+*/
+func NewTemplate(path string) (*Template, error) {
+	tmpl, err := template.New(filepath.Base(path)).Funcs(templateFuncs()).ParseFiles(path)
+	if err != nil {
+		return nil, xerrors.Errorf("error parsing template: %w", err)
+	}
+	return &Template{template: tmpl}, nil
+}
+
+/*
+Render executes the template with the report.
+*/
+func (t *Template) Render(w io.Writer, report *Report) error {
+	if err := t.template.Execute(w, report); err != nil {
+		return xerrors.Errorf("error executing template: %w", err)
+	}
+	return nil
+}
+
+func templateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"date":     formatDate,
+		"groupBy":  groupBy,
+		"truncate": truncate,
+		"join":     strings.Join,
+		"lower":    strings.ToLower,
+		"upper":    strings.ToUpper,
+	}
+}
+
+func formatDate(layout string, t time.Time) string {
+	return t.Format(layout)
+}
+
+func groupBy(field string, items []source.Item) ([]Group, error) {
+	keys, err := groupKeys(field)
+	if err != nil {
+		return nil, err
+	}
+	var groups []Group
+	index := make(map[string]int)
+	for _, item := range items {
+		for _, k := range keys(item) {
+			i, ok := index[k]
+			if !ok {
+				i = len(groups)
+				index[k] = i
+				groups = append(groups, Group{Key: k})
+			}
+			groups[i].Items = append(groups[i].Items, item)
+		}
+	}
+	return groups, nil
+}
+
+/*
+groupKeys returns a function returning the keys of the groups an item belongs to when grouped by the given field.
+*/
+func groupKeys(field string) (func(source.Item) []string, error) {
+	switch field {
+	case "source":
+		return func(item source.Item) []string { return []string{item.Source} }, nil
+	case "workspace":
+		return func(item source.Item) []string { return []string{item.Workspace} }, nil
+	case "project":
+		return func(item source.Item) []string { return []string{item.Project} }, nil
+	case "section":
+		return func(item source.Item) []string { return []string{item.Section} }, nil
+	case "tag":
+		return func(item source.Item) []string {
+			if len(item.Tags) == 0 {
+				return []string{""}
+			}
+			return item.Tags
+		}, nil
+	default:
+		return nil, xerrors.Errorf("cannot group by %q", field)
+	}
+}
+
+func truncate(length int, text string) string {
+	if utf8.RuneCountInString(text) <= length {
+		return text
+	}
+	if length < 1 {
+		return ""
+	}
+	runes := []rune(text)
+	return string(runes[:length-1]) + "…"
+}
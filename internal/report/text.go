@@ -0,0 +1,75 @@
+package report
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jeremy-miller/standup-reporter/internal/source"
+)
+
+/*
+Text renders a report as plain text, with each item on its own line.  Items of grouped sections are indented under the
+title of their group.
+*/
+type Text struct{}
+
+/*
+Render writes the report as plain text.  Errors are written before the sections.
+*/
+func (Text) Render(w io.Writer, report *Report) error {
+	bw := bufio.NewWriter(w)
+	for _, err := range report.Errors {
+		fmt.Fprintf(bw, "\n%v\n", err)
+	}
+	workspace := ""
+	for _, section := range report.Sections {
+		if section.Workspace != workspace {
+			workspace = section.Workspace
+			fmt.Fprintf(bw, "\nWorkspace: %s\n", workspace)
+		}
+		fmt.Fprintf(bw, "\n%s:\n", section.Title)
+		if len(section.Groups) > 0 {
+			for _, group := range section.Groups {
+				fmt.Fprintln(bw, "-", groupTitle(group))
+				for _, item := range group.Items {
+					writeTextItem(bw, "  ", item, section.Kind, report.RollUp)
+				}
+			}
+			continue
+		}
+		for _, item := range section.Items {
+			writeTextItem(bw, "", item, section.Kind, report.RollUp)
+		}
+	}
+	fmt.Fprintln(bw)
+	return bw.Flush()
+}
+
+/*
+writeTextItem writes an item as a list entry with the given indentation, followed by its subtasks unless they are rolled
+up.
+*/
+func writeTextItem(w io.Writer, indent string, item source.Item, kind Kind, rollUp bool) {
+	if rollUp && item.SubtaskCount > 0 {
+		item.Name = rolledUpName(item.Name, item, kind)
+	}
+	fmt.Fprintf(w, "%s- %s\n", indent, textItem(item))
+	if rollUp {
+		return
+	}
+	for _, subtask := range item.Subtasks {
+		fmt.Fprintf(w, "%s  - %s\n", indent, textItem(subtask))
+	}
+}
+
+/*
+textItem returns the text of an item, which is its name followed by the items blocking it, if any.
+*/
+func textItem(item source.Item) string {
+	if len(item.BlockedBy) == 0 {
+		return item.Name
+	}
+	return fmt.Sprintf("%s (blocked by %s)", item.Name, strings.Join(item.BlockedBy, ", "))
+}
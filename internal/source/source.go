@@ -0,0 +1,229 @@
+/*
+Package source defines the interface implemented by the task trackers (e.g. Asana) which provide data for standup
+reports.
+
+Sources are registered by name and enabled through the configuration.  Enabled sources are queried concurrently and
+their items are merged into a single result: completed items and activity are sorted oldest to most recent, while
+planned items are kept in the order of the enabled sources.
+*/
+package source
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"github.com/jeremy-miller/standup-reporter/internal/configuration"
+)
+
+/*
+Item is a single unit of work (e.g. a task) retrieved from a source.
+*/
+type Item struct {
+	Source       string    // Name of the source the item was retrieved from.
+	Workspace    string    // Workspace the item belongs to, if the source retrieved items from more than one workspace.
+	Project      string    // Project the item belongs to, if known.
+	Section      string    // Section of the project the item is in, if known.
+	Tags         []string  // Tags of the item, if any.
+	Name         string    // Name of the item.
+	URL          string    // Link to the item in the source's web application, if known.
+	CreatedAt    time.Time // Time the item was created, if known.
+	Due          time.Time // Time the item is due (midnight if it is due on a date), or the zero time if it isn't due.
+	CompletedAt  time.Time // Time the item was completed (or the activity happened), or the zero time if incomplete.
+	Blocked      bool      // Whether the incomplete item is blocked, in which case it is reported as a blocker.
+	BlockedBy    []string  // Names of the incomplete items this item depends on, if any.
+	Subtasks     []Item    // Subtasks reported with the item (e.g. those completed within the window), if any.
+	SubtaskCount int       // Number of subtasks of the item, including those which aren't reported.
+	SubtasksDone int       // Number of completed subtasks of the item.
+}
+
+/*
+Source is implemented by each task tracker.  A source may return items along with an error if only some of its items
+could be retrieved.
+*/
+type Source interface {
+	Name() string                                                          // Name of the source.
+	Completed(ctx context.Context, since, until time.Time) ([]Item, error) // Items completed within [since, until).
+	Planned(ctx context.Context) ([]Item, error)                           // Incomplete items.
+}
+
+/*
+UserNamer is optionally implemented by sources which know the name of the user the report is for.
+*/
+type UserNamer interface {
+	UserName(ctx context.Context) (string, error)
+}
+
+/*
+ActivityReporter is optionally implemented by sources which report work other than completing items (e.g. comments).
+*/
+type ActivityReporter interface {
+	Activity(ctx context.Context, since, until time.Time) ([]Item, error) // Activity within [since, until).
+}
+
+/*
+Factory creates a source from the configuration.
+*/
+type Factory func(config *configuration.Configuration) (Source, error)
+
+var (
+	registryMu sync.RWMutex               //nolint:gochecknoglobals
+	registry   = make(map[string]Factory) //nolint:gochecknoglobals
+)
+
+/*
+Register makes a source available under the given name.  It panics if the factory is nil or if a source with the same
+name is already registered.
+*/
+func Register(name string, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if factory == nil {
+		panic("source: Register factory is nil")
+	}
+	if _, dup := registry[name]; dup {
+		panic("source: Register called twice for source " + name)
+	}
+	registry[name] = factory
+}
+
+/*
+Names returns the sorted names of all registered sources.
+*/
+func Names() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+/*
+New creates the sources with the given names, in the same order.
+*/
+func New(names []string, config *configuration.Configuration) ([]Source, error) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	sources := make([]Source, 0, len(names))
+	for _, name := range names {
+		factory, ok := registry[name]
+		if !ok {
+			return nil, xerrors.Errorf("unknown source \"%s\"", name)
+		}
+		src, err := factory(config)
+		if err != nil {
+			return nil, xerrors.Errorf("error creating source %s: %w", name, err)
+		}
+		sources = append(sources, src)
+	}
+	return sources, nil
+}
+
+/*
+Result contains the merged items of all sources.
+*/
+type Result struct {
+	User      string  // Name of the user, from the first source which provides it.
+	Completed []Item  // Completed items, sorted oldest to most recently completed.
+	Planned   []Item  // Incomplete items, in the order of the sources.
+	Activity  []Item  // Activity other than completing items, sorted oldest to most recent.
+	Errors    []error // Errors of individual sources (see Error), at most one per source.
+}
+
+/*
+Error is an error retrieving the items of a source.
+*/
+type Error struct {
+	Source string // Name of the source.
+	Err    error  // Error returned by the source.
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("error retrieving %s data: %v", e.Source, e.Err)
+}
+
+/*
+Unwrap returns the error returned by the source.
+*/
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+type sourceResult struct {
+	user      string
+	completed []Item
+	planned   []Item
+	activity  []Item
+	err       error
+}
+
+/*
+Collect queries all sources concurrently for items completed within [since, until), for planned items and for activity
+within [since, until), and merges their results.  Items of sources which failed only partially are included alongside
+their errors.
+*/
+func Collect(ctx context.Context, sources []Source, since, until time.Time) *Result {
+	results := make([]sourceResult, len(sources))
+	var wg sync.WaitGroup
+	for i, src := range sources {
+		wg.Add(1)
+		go func(i int, src Source) {
+			defer wg.Done()
+			results[i] = collect(ctx, src, since, until)
+		}(i, src)
+	}
+	wg.Wait()
+	result := &Result{}
+	for _, r := range results {
+		if result.User == "" {
+			result.User = r.user
+		}
+		result.Completed = append(result.Completed, r.completed...)
+		result.Planned = append(result.Planned, r.planned...)
+		result.Activity = append(result.Activity, r.activity...)
+		if r.err != nil {
+			result.Errors = append(result.Errors, r.err)
+		}
+	}
+	sort.SliceStable(result.Completed, func(i, j int) bool {
+		return result.Completed[i].CompletedAt.Before(result.Completed[j].CompletedAt)
+	})
+	sort.SliceStable(result.Activity, func(i, j int) bool {
+		return result.Activity[i].CompletedAt.Before(result.Activity[j].CompletedAt)
+	})
+	return result
+}
+
+func collect(ctx context.Context, src Source, since, until time.Time) sourceResult {
+	var r sourceResult
+	completed, completedErr := src.Completed(ctx, since, until)
+	r.completed = completed
+	planned, plannedErr := src.Planned(ctx)
+	r.planned = planned
+	var activityErr error
+	if reporter, ok := src.(ActivityReporter); ok {
+		r.activity, activityErr = reporter.Activity(ctx, since, until)
+	}
+	var userErr error
+	if namer, ok := src.(UserNamer); ok {
+		r.user, userErr = namer.UserName(ctx)
+	}
+	switch {
+	case completedErr != nil:
+		r.err = &Error{Source: src.Name(), Err: completedErr}
+	case plannedErr != nil:
+		r.err = &Error{Source: src.Name(), Err: plannedErr}
+	case activityErr != nil:
+		r.err = &Error{Source: src.Name(), Err: activityErr}
+	case userErr != nil:
+		r.err = &Error{Source: src.Name(), Err: userErr}
+	}
+	return r
+}
@@ -0,0 +1,201 @@
+package source_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/xerrors"
+
+	"github.com/jeremy-miller/standup-reporter/internal/configuration"
+	"github.com/jeremy-miller/standup-reporter/internal/source"
+)
+
+type fakeSource struct {
+	name         string
+	completed    []source.Item
+	planned      []source.Item
+	completedErr error
+	plannedErr   error
+}
+
+func (f *fakeSource) Name() string {
+	return f.name
+}
+
+func (f *fakeSource) Completed(ctx context.Context, since, until time.Time) ([]source.Item, error) {
+	return f.completed, f.completedErr
+}
+
+func (f *fakeSource) Planned(ctx context.Context) ([]source.Item, error) {
+	return f.planned, f.plannedErr
+}
+
+func TestRegisterNew(t *testing.T) {
+	assert := assert.New(t)
+	source.Register("fake-new", func(config *configuration.Configuration) (source.Source, error) {
+		return &fakeSource{name: "fake-new"}, nil
+	})
+	assert.Contains(source.Names(), "fake-new")
+	sources, err := source.New([]string{"fake-new"}, &configuration.Configuration{})
+	assert.Nil(err)
+	assert.Len(sources, 1)
+	assert.Equal("fake-new", sources[0].Name())
+}
+
+func TestRegisterDuplicate(t *testing.T) {
+	factory := func(config *configuration.Configuration) (source.Source, error) {
+		return &fakeSource{name: "fake-duplicate"}, nil
+	}
+	source.Register("fake-duplicate", factory)
+	assert.Panics(t, func() { source.Register("fake-duplicate", factory) })
+}
+
+func TestRegisterNil(t *testing.T) {
+	assert.Panics(t, func() { source.Register("fake-nil", nil) })
+}
+
+func TestNewUnknown(t *testing.T) {
+	_, err := source.New([]string{"unknown"}, &configuration.Configuration{})
+	assert.EqualError(t, err, "unknown source \"unknown\"")
+}
+
+func TestNewFactoryError(t *testing.T) {
+	source.Register("fake-error", func(config *configuration.Configuration) (source.Source, error) {
+		return nil, xerrors.New("invalid configuration")
+	})
+	_, err := source.New([]string{"fake-error"}, &configuration.Configuration{})
+	assert.EqualError(t, err, "error creating source fake-error: invalid configuration")
+}
+
+func TestCollectMergesSources(t *testing.T) {
+	assert := assert.New(t)
+	now := time.Now().Local()
+	completedAt1 := time.Date(now.Year(), now.Month(), now.Day()-1, 13, 0, 0, 0, time.Local)
+	completedAt2 := time.Date(now.Year(), now.Month(), now.Day()-1, 12, 0, 0, 0, time.Local)
+	sources := []source.Source{
+		&fakeSource{
+			name:      "fake 1",
+			completed: []source.Item{{Source: "fake 1", Name: "Task 1", CompletedAt: completedAt1}},
+			planned:   []source.Item{{Source: "fake 1", Name: "Task 2"}},
+		},
+		&fakeSource{
+			name:      "fake 2",
+			completed: []source.Item{{Source: "fake 2", Name: "Task 3", CompletedAt: completedAt2}},
+			planned:   []source.Item{{Source: "fake 2", Name: "Task 4"}},
+		},
+	}
+	result := source.Collect(context.Background(), sources, completedAt2.AddDate(0, 0, -1), now)
+	expectedCompleted := []source.Item{
+		{Source: "fake 2", Name: "Task 3", CompletedAt: completedAt2},
+		{Source: "fake 1", Name: "Task 1", CompletedAt: completedAt1},
+	}
+	assert.Equal(expectedCompleted, result.Completed)
+	expectedPlanned := []source.Item{
+		{Source: "fake 1", Name: "Task 2"},
+		{Source: "fake 2", Name: "Task 4"},
+	}
+	assert.Equal(expectedPlanned, result.Planned)
+	assert.Empty(result.Errors)
+}
+
+func TestCollectPartialFailure(t *testing.T) {
+	assert := assert.New(t)
+	sources := []source.Source{
+		&fakeSource{
+			name:         "fake 1",
+			planned:      []source.Item{{Source: "fake 1", Name: "Task 1"}},
+			completedErr: xerrors.New("project 1 failed"),
+			plannedErr:   xerrors.New("project 1 failed"),
+		},
+		&fakeSource{
+			name:       "fake 2",
+			plannedErr: xerrors.New("unavailable"),
+		},
+	}
+	now := time.Now()
+	result := source.Collect(context.Background(), sources, now.AddDate(0, 0, -1), now)
+	expectedPlanned := []source.Item{
+		{Source: "fake 1", Name: "Task 1"},
+	}
+	assert.Equal(expectedPlanned, result.Planned)
+	assert.Len(result.Errors, 2)
+	assert.EqualError(result.Errors[0], "error retrieving fake 1 data: project 1 failed")
+	assert.EqualError(result.Errors[1], "error retrieving fake 2 data: unavailable")
+}
+
+func TestCollectSourceError(t *testing.T) {
+	assert := assert.New(t)
+	sourceErr := xerrors.New("unavailable")
+	sources := []source.Source{&fakeSource{name: "fake", completedErr: sourceErr}}
+	now := time.Now()
+	result := source.Collect(context.Background(), sources, now.AddDate(0, 0, -1), now)
+	assert.Len(result.Errors, 1)
+	var err *source.Error
+	assert.True(xerrors.As(result.Errors[0], &err))
+	assert.Equal("fake", err.Source)
+	assert.True(xerrors.Is(result.Errors[0], sourceErr))
+}
+
+type fakeUserSource struct {
+	fakeSource
+	userName string
+	userErr  error
+}
+
+func (f *fakeUserSource) UserName(ctx context.Context) (string, error) {
+	return f.userName, f.userErr
+}
+
+type fakeActivitySource struct {
+	fakeSource
+	activity    []source.Item
+	activityErr error
+}
+
+func (f *fakeActivitySource) Activity(ctx context.Context, since, until time.Time) ([]source.Item, error) {
+	return f.activity, f.activityErr
+}
+
+func TestCollectActivity(t *testing.T) {
+	assert := assert.New(t)
+	now := time.Now().Local()
+	createdAt1 := time.Date(now.Year(), now.Month(), now.Day()-1, 13, 0, 0, 0, time.Local)
+	createdAt2 := time.Date(now.Year(), now.Month(), now.Day()-1, 12, 0, 0, 0, time.Local)
+	sources := []source.Source{
+		&fakeActivitySource{
+			fakeSource: fakeSource{name: "fake 1"},
+			activity:   []source.Item{{Source: "fake 1", Name: "Commented on Task 1", CompletedAt: createdAt1}},
+		},
+		&fakeSource{name: "fake 2"},
+		&fakeActivitySource{
+			fakeSource:  fakeSource{name: "fake 3"},
+			activity:    []source.Item{{Source: "fake 3", Name: "Commented on Task 2", CompletedAt: createdAt2}},
+			activityErr: xerrors.New("task 3 not found"),
+		},
+	}
+	result := source.Collect(context.Background(), sources, createdAt2.AddDate(0, 0, -1), now)
+	expectedActivity := []source.Item{
+		{Source: "fake 3", Name: "Commented on Task 2", CompletedAt: createdAt2},
+		{Source: "fake 1", Name: "Commented on Task 1", CompletedAt: createdAt1},
+	}
+	assert.Equal(expectedActivity, result.Activity)
+	assert.Len(result.Errors, 1)
+	assert.EqualError(result.Errors[0], "error retrieving fake 3 data: task 3 not found")
+}
+
+func TestCollectUserName(t *testing.T) {
+	assert := assert.New(t)
+	sources := []source.Source{
+		&fakeSource{name: "fake 1"},
+		&fakeUserSource{fakeSource: fakeSource{name: "fake 2"}, userErr: xerrors.New("unauthorized")},
+		&fakeUserSource{fakeSource: fakeSource{name: "fake 3"}, userName: "User 3"},
+		&fakeUserSource{fakeSource: fakeSource{name: "fake 4"}, userName: "User 4"},
+	}
+	now := time.Now()
+	result := source.Collect(context.Background(), sources, now.AddDate(0, 0, -1), now)
+	assert.Equal("User 3", result.User)
+	assert.Len(result.Errors, 1)
+	assert.EqualError(result.Errors[0], "error retrieving fake 2 data: unauthorized")
+}